@@ -3,211 +3,197 @@ package casecount
 import (
 	"fmt"
 	"strings"
-	"sync"
+
 	"yet-another-covid-map-api/utils"
 )
 
-type aggregatedCaseCountsMap struct {
-	country string
-	info    CountryWithStatesAggregated
-}
-
-type countryMap struct {
-	country string
-	info    Country
-}
-
-type countryAggMap struct {
-	country string
-	info    CountryAggregated
-}
-
-func copyAndFilterCaseCountsMap(countryInfo CountryWithStates, fromIndex int, toIndex int) CountryWithStates {
-	newInfo := CountryWithStates{countryInfo.Name, make(map[string]CaseCounts, len(countryInfo.States))}
-	for state, stateInfo := range countryInfo.States {
-		newStateInfo := CaseCounts{stateInfo.LocationAndPopulation, stateInfo.Counts[fromIndex : toIndex+1]}
-		newInfo.States[state] = newStateInfo
+// selectStates : get the states of the country that are in the query, which are all of them if there are none in the query
+func (c *countrySeries) selectStates(states []string) map[string]*locationSeries {
+	if len(states) == 0 {
+		return c.states
+	}
+	selected := make(map[string]*locationSeries, len(states))
+	for _, state := range states {
+		if stateInfo, ok := c.states[state]; ok {
+			selected[state] = stateInfo
+		}
 	}
-	return newInfo
+	return selected
 }
 
-func aggregateCaseCountsMap(countryInfo CountryWithStates, fromIndex int, toIndex int) CountryWithStatesAggregated {
-	newInfo := CountryWithStatesAggregated{countryInfo.Name, make(map[string]CaseCountsAggregated, len(countryInfo.States))}
-	for state, stateInfo := range countryInfo.States {
-		confirmedSum, deathsSum, recoveredSum := getStatisticsSum(stateInfo.Counts, fromIndex, toIndex)
-		newStateInfo := CaseCountsAggregated{stateInfo.LocationAndPopulation, statistics{confirmedSum, deathsSum, recoveredSum}}
-		newInfo.States[state] = newStateInfo
+func (c *countrySeries) toCountryWithStates(dates []string, periods []period, selectedStates []string) CountryWithStates {
+	states := c.selectStates(selectedStates)
+	newInfo := CountryWithStates{c.name, make(map[string]CaseCounts, len(states))}
+	for state, stateInfo := range states {
+		newInfo.States[state] = CaseCounts{stateInfo.LocationAndPopulation, stateInfo.toCaseCounts(dates, periods)}
 	}
 	return newInfo
 }
 
-func filterCaseCounts(from string, to string, country string) (map[string]CountryWithStates, error) {
-	fromIndex, toIndex := getFromAndToIndices(from, to)
-	filteredCaseCounts := make(map[string]CountryWithStates)
-	if fromIndex > toIndex {
-		return filteredCaseCounts, fmt.Errorf("From date %s cannot be after to date %s", from, to)
+func (c *countrySeries) toCountryWithStatesAggregated(fromIndex int, toIndex int, selectedStates []string) CountryWithStatesAggregated {
+	states := c.selectStates(selectedStates)
+	newInfo := CountryWithStatesAggregated{c.name, make(map[string]CaseCountsAggregated, len(states))}
+	for state, stateInfo := range states {
+		newInfo.States[state] = CaseCountsAggregated{stateInfo.LocationAndPopulation, stateInfo.getStatisticsSum(fromIndex, toIndex)}
 	}
-	if country != "" {
-		if countryInfo, ok := caseCountsMap[country]; ok {
-			filteredCaseCounts[country] = copyAndFilterCaseCountsMap(countryInfo, fromIndex, toIndex)
-			return filteredCaseCounts, nil
-		}
-	}
-	for countryKey, countryInfo := range caseCountsMap {
-		countryName, _ := utils.GetCountryFromAbbreviation(countryKey)
-		if country == "" || strings.ToLower(country) == strings.ToLower(countryName) {
-			filteredCaseCounts[countryKey] = copyAndFilterCaseCountsMap(countryInfo, fromIndex, toIndex)
-		}
-	}
-	return filteredCaseCounts, nil
+	return newInfo
 }
 
-func syncAggregateCaseCountsMap(countryKey string, countryInfo CountryWithStates, fromIndex int, toIndex int, country string, ch chan aggregatedCaseCountsMap, wg *sync.WaitGroup) {
-	countryName, _ := utils.GetCountryFromAbbreviation(countryKey)
-	if country == "" || strings.ToLower(countryName) == strings.ToLower(country) {
-		info := aggregateCaseCountsMap(countryInfo, fromIndex, toIndex)
-		ch <- aggregatedCaseCountsMap{countryKey, info}
-	}
-	wg.Done()
+func (c *countrySeries) toCountry(dates []string, periods []period) Country {
+	return Country{c.name, CaseCounts{c.total.LocationAndPopulation, c.total.toCaseCounts(dates, periods)}}
 }
 
-func aggregateDataBetweenDates(from string, to string, country string) (map[string]CountryWithStatesAggregated, error) {
-	fromIndex, toIndex := getFromAndToIndices(from, to)
-	aggregatedData := make(map[string]CountryWithStatesAggregated)
-	if fromIndex > toIndex {
-		return aggregatedData, fmt.Errorf("From date %s cannot be after to date %s", from, to)
-	}
-	if country != "" {
-		if countryInfo, ok := caseCountsMap[country]; ok {
-			aggregatedData[country] = aggregateCaseCountsMap(countryInfo, fromIndex, toIndex)
-			return aggregatedData, nil
-		}
-	}
-	ch := make(chan aggregatedCaseCountsMap, len(caseCountsMap))
-	wg := sync.WaitGroup{}
-	for countryKey, countryInfo := range caseCountsMap {
-		wg.Add(1)
-		go syncAggregateCaseCountsMap(countryKey, countryInfo, fromIndex, toIndex, country, ch, &wg)
-	}
-	wg.Wait()
-	close(ch)
-	for caseCountsAgg := range ch {
-		aggregatedData[caseCountsAgg.country] = caseCountsAgg.info
-	}
-	return aggregatedData, nil
+func (c *countrySeries) toCountryAggregated(fromIndex int, toIndex int) CountryAggregated {
+	return CountryAggregated{c.name, CaseCountsAggregated{c.total.LocationAndPopulation, c.total.getStatisticsSum(fromIndex, toIndex)}}
 }
 
-func syncSumStates(country string, countryInfo map[string]CaseCounts, ch chan countryMap, wg *sync.WaitGroup) {
+// getCountryTotal : sum the time series of all states in the country, and use the location of the country itself if it is available
+// or the average location of the states if it is not
+func getCountryTotal(states map[string]*locationSeries) locationSeries {
 	var latSum, longSum float32
 	var count, population int
-	var counts []CaseCount
-	for _, stateInfo := range countryInfo {
+	var series timeSeries
+	for _, stateInfo := range states {
 		population += stateInfo.Population
 		latSum += stateInfo.Lat
 		longSum += stateInfo.Long
 		count++
-		if counts == nil {
-			counts = make([]CaseCount, len(stateInfo.Counts))
-			copy(counts, stateInfo.Counts)
+		if series.confirmed == nil {
+			series = stateInfo.timeSeries.copy()
 		} else {
-			for index := range counts {
-				counts[index].Confirmed += stateInfo.Counts[index].Confirmed
-				counts[index].Deaths += stateInfo.Counts[index].Deaths
-				counts[index].Recovered += stateInfo.Counts[index].Recovered
-			}
+			series.add(stateInfo.timeSeries)
 		}
 	}
 	var lat, long float32
-	if info, ok := countryInfo[""]; ok {
+	if info, ok := states[""]; ok {
 		lat, long = info.Lat, info.Long
 		population = info.Population
 	} else {
 		countF := float32(count)
 		lat, long = latSum/countF, longSum/countF
 	}
-	countryName, _ := utils.GetCountryFromAbbreviation(country)
-	ch <- countryMap{country, Country{countryName, CaseCounts{LocationAndPopulation{lat, long, population}, counts}}}
-	wg.Done()
+	return locationSeries{LocationAndPopulation{lat, long, population}, series}
 }
 
-func aggregateCountryDataFromCaseCounts(caseCountsMap map[string]CountryWithStates) map[string]Country {
-	ch := make(chan countryMap, len(caseCountsMap))
-	wg := sync.WaitGroup{}
-	aggregatedData := make(map[string]Country)
-	for country, countryInfo := range caseCountsMap {
-		wg.Add(1)
-		go syncSumStates(country, countryInfo.States, ch, &wg)
-	}
-	wg.Wait()
-	close(ch)
-	for caseCountsAgg := range ch {
-		aggregatedData[caseCountsAgg.country] = caseCountsAgg.info
-	}
-	return aggregatedData
+func getWorldTotal(countries map[string]*countrySeries, numDays int) timeSeries {
+	series := newTimeSeries(numDays)
+	for _, countryInfo := range countries {
+		series.add(countryInfo.total.timeSeries)
+	}
+	return series
 }
 
-func syncSumStatesAggregated(country string, countryInfo map[string]CaseCountsAggregated, ch chan countryAggMap, wg *sync.WaitGroup) {
-	var latSum, longSum float32
-	var count, confirmed, deaths, recovered, population int
-	for _, stateInfo := range countryInfo {
-		latSum += stateInfo.Lat
-		longSum += stateInfo.Long
-		count++
-		confirmed += stateInfo.Confirmed
-		deaths += stateInfo.Deaths
-		recovered += stateInfo.Recovered
-		population += stateInfo.Population
+// selectCountries : get the iso codes of the countries matching the country in the query, which can be an iso code or a country name
+func (d *Dataset) selectCountries(country string) []string {
+	if country == "" {
+		countries := make([]string, 0, len(d.countries))
+		for countryKey := range d.countries {
+			countries = append(countries, countryKey)
+		}
+		return countries
 	}
-	var lat, long float32
-	if info, ok := countryInfo[""]; ok {
-		lat, long = info.Lat, info.Long
-		population = info.Population
-	} else {
-		countF := float32(count)
-		lat, long = latSum/countF, longSum/countF
+	if _, ok := d.countries[country]; ok {
+		return []string{country}
+	}
+	var countries []string
+	for countryKey, countryInfo := range d.countries {
+		if strings.ToLower(country) == strings.ToLower(countryInfo.name) {
+			countries = append(countries, countryKey)
+		}
 	}
-	countryName, _ := utils.GetCountryFromAbbreviation(country)
-	ch <- countryAggMap{country, CountryAggregated{countryName, CaseCountsAggregated{LocationAndPopulation{lat, long, population}, statistics{confirmed, deaths, recovered}}}}
-	wg.Done()
+	return countries
 }
 
-func aggregateCountryDataFromStatesAggregate(caseCountsMap map[string]CountryWithStatesAggregated) map[string]CountryAggregated {
-	ch := make(chan countryAggMap, len(caseCountsMap))
-	wg := sync.WaitGroup{}
-	aggregatedData := make(map[string]CountryAggregated)
-	for country, countryInfo := range caseCountsMap {
-		wg.Add(1)
-		go syncSumStatesAggregated(country, countryInfo.States, ch, &wg)
+func (d *Dataset) filterCaseCounts(from string, to string, country string, states []string, interval string, dateFormat string) (map[string]CountryWithStates, error) {
+	fromIndex, toIndex := d.getFromAndToIndices(from, to)
+	filteredCaseCounts := make(map[string]CountryWithStates)
+	if !d.isLoaded() {
+		return filteredCaseCounts, nil
 	}
-	wg.Wait()
-	close(ch)
-	for caseCountsAgg := range ch {
-		aggregatedData[caseCountsAgg.country] = caseCountsAgg.info
+	if fromIndex > toIndex {
+		return filteredCaseCounts, fmt.Errorf("From date %s cannot be after to date %s", from, to)
 	}
-	return aggregatedData
+	countries := d.selectCountries(country)
+	periods := d.getPeriods(fromIndex, toIndex, interval)
+	dates := d.getDates(dateFormat)
+	results := make([]CountryWithStates, len(countries))
+	utils.DefaultWorkerPool.Run(len(countries), func(index int) {
+		results[index] = d.countries[countries[index]].toCountryWithStates(dates, periods, states)
+	})
+	for index, countryKey := range countries {
+		if len(results[index].States) > 0 {
+			filteredCaseCounts[countryKey] = results[index]
+		}
+	}
+	return filteredCaseCounts, nil
 }
 
-func aggregateWorldData(caseCounts map[string]Country) []CaseCount {
-	var counts []CaseCount
-	for _, countryInfo := range caseCounts {
-		if counts == nil {
-			counts = make([]CaseCount, len(countryInfo.Counts))
-			copy(counts, countryInfo.Counts)
-		} else {
-			for index := range counts {
-				counts[index].Confirmed += countryInfo.Counts[index].Confirmed
-				counts[index].Deaths += countryInfo.Counts[index].Deaths
-				counts[index].Recovered += countryInfo.Counts[index].Recovered
-			}
+func (d *Dataset) filterCountryCaseCounts(from string, to string, country string, interval string, dateFormat string) (map[string]Country, error) {
+	fromIndex, toIndex := d.getFromAndToIndices(from, to)
+	filteredCaseCounts := make(map[string]Country)
+	if !d.isLoaded() {
+		return filteredCaseCounts, nil
+	}
+	if fromIndex > toIndex {
+		return filteredCaseCounts, fmt.Errorf("From date %s cannot be after to date %s", from, to)
+	}
+	countries := d.selectCountries(country)
+	periods := d.getPeriods(fromIndex, toIndex, interval)
+	dates := d.getDates(dateFormat)
+	results := make([]Country, len(countries))
+	utils.DefaultWorkerPool.Run(len(countries), func(index int) {
+		results[index] = d.countries[countries[index]].toCountry(dates, periods)
+	})
+	for index, countryKey := range countries {
+		filteredCaseCounts[countryKey] = results[index]
+	}
+	return filteredCaseCounts, nil
+}
+
+func (d *Dataset) aggregateDataBetweenDates(from string, to string, country string, states []string) (map[string]CountryWithStatesAggregated, error) {
+	fromIndex, toIndex := d.getFromAndToIndices(from, to)
+	aggregatedData := make(map[string]CountryWithStatesAggregated)
+	if !d.isLoaded() {
+		return aggregatedData, nil
+	}
+	if fromIndex > toIndex {
+		return aggregatedData, fmt.Errorf("From date %s cannot be after to date %s", from, to)
+	}
+	countries := d.selectCountries(country)
+	results := make([]CountryWithStatesAggregated, len(countries))
+	utils.DefaultWorkerPool.Run(len(countries), func(index int) {
+		results[index] = d.countries[countries[index]].toCountryWithStatesAggregated(fromIndex, toIndex, states)
+	})
+	for index, countryKey := range countries {
+		if len(results[index].States) > 0 {
+			aggregatedData[countryKey] = results[index]
 		}
 	}
-	return counts
+	return aggregatedData, nil
+}
+
+func (d *Dataset) aggregateCountryDataBetweenDates(from string, to string, country string) (map[string]CountryAggregated, error) {
+	fromIndex, toIndex := d.getFromAndToIndices(from, to)
+	aggregatedData := make(map[string]CountryAggregated)
+	if !d.isLoaded() {
+		return aggregatedData, nil
+	}
+	if fromIndex > toIndex {
+		return aggregatedData, fmt.Errorf("From date %s cannot be after to date %s", from, to)
+	}
+	for _, countryKey := range d.selectCountries(country) {
+		aggregatedData[countryKey] = d.countries[countryKey].toCountryAggregated(fromIndex, toIndex)
+	}
+	return aggregatedData, nil
 }
 
-func getWorldDataBetweenDates(from string, to string) ([]CaseCount, error) {
-	fromIndex, toIndex := getFromAndToIndices(from, to)
+func (d *Dataset) getWorldDataBetweenDates(from string, to string, interval string, dateFormat string) ([]CaseCount, error) {
+	if !d.isLoaded() {
+		return nil, nil
+	}
+	fromIndex, toIndex := d.getFromAndToIndices(from, to)
 	if fromIndex > toIndex {
 		return nil, fmt.Errorf("From date %s cannot be after to date %s", from, to)
 	}
-	return worldCaseCountsCache[fromIndex : toIndex+1], nil
+	return d.world.toCaseCounts(d.getDates(dateFormat), d.getPeriods(fromIndex, toIndex, interval)), nil
 }
@@ -1,8 +1,10 @@
 package casecount
 
 import (
+	"fmt"
 	"log"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,17 +22,7 @@ const (
 )
 
 var (
-	// cache the query for getting all data for all states and all countries, because it is the most heavily used
-	stateAggregatedMap   map[string]CountryWithStatesAggregated
-	countryAggregatedMap map[string]CountryAggregated
-
-	caseCountsMap        map[string]CountryWithStates
-	countryCaseCountsMap map[string]Country
-	worldCaseCountsCache []CaseCount
-
-	lastDate  time.Time
-	firstDate time.Time
-
+	// serialises updates, queries never need to take this lock because they read from an immutable dataset
 	mux sync.Mutex
 
 	client utils.HTTPClient
@@ -43,77 +35,122 @@ func init() {
 // UpdateCaseCounts : Pull data from the John Hopkins CSV files on GitHub, store the result in a cache and also cache the aggregate data for the entire period
 func UpdateCaseCounts() {
 	log.Println("Updating case counts")
-	confirmedData, deathsData, recoveredData, usConfirmedData, usDeathsData, ok := getData()
-	if !ok || len(confirmedData) < 2 || len(confirmedData) != len(deathsData) {
-		log.Println("New data is faulty, continuing to use old data.")
+	countries, headerRow, err := ingestCaseCounts()
+	if err != nil {
+		log.Printf("New data is faulty, continuing to use old data: %s\n", err.Error())
 		return
 	}
-	headerRow := confirmedData[0]
-	baseCaseCountsMap := extractCaseCounts(headerRow, confirmedData, deathsData, recoveredData)
-	usCaseCounts := extractUSCaseCounts(usConfirmedData, usDeathsData)
 	mux.Lock()
 	defer mux.Unlock()
-	caseCountsMap = mergeCaseCountsWithUS(baseCaseCountsMap, usCaseCounts)
-	setDateBoundariesAndAllAggregatedData(headerRow)
+	publishDataset(newDataset(countries, headerRow))
 }
 
-// GetCaseCountsWithDayData : get case counts for states but without aggregating the counts, so a list of days with number of confirmed cases and deaths on each day is returned
-func GetCaseCountsWithDayData(from string, to string, country string) (map[string]CountryWithStates, error) {
-	if from == "" && to == "" && country == "" {
-		log.Println("GetCaseCounts query for all data with per day information")
-		return caseCountsMap, nil
+// GetCaseCountsWithDayData : get case counts for states but without aggregating the counts, so a list of days with number of confirmed cases and deaths on each day is returned.
+// If interval is a week or month, there is one item for each period instead of each day. If states is not empty, only the states with those names are returned.
+func GetCaseCountsWithDayData(from string, to string, country string, states []string, interval string, dateFormat string) (map[string]CountryWithStates, error) {
+	dataset := getDataset()
+	log.Printf("GetCaseCountsWithDayData query from: %s, to: %s, country: %s, states: %v, interval: %s, dateFormat: %s\n", from, to, country, states, interval, dateFormat)
+	if !IsValidInterval(interval) {
+		return map[string]CountryWithStates{}, fmt.Errorf("Interval %s is not supported", interval)
 	}
-	log.Printf("GetCaseCountsWithDayData query from: %s, to: %s, country: %s\n", from, to, country)
-	return filterCaseCounts(from, to, country)
+	if !dateformat.IsValidOutputFormat(dateFormat) {
+		return map[string]CountryWithStates{}, fmt.Errorf("Date format %s is not supported", dateFormat)
+	}
+	result, err := coalescer.do(dataset.getQueryKey("GetCaseCountsWithDayData", from, to, country, strings.Join(states, ","), interval, dateFormat), func() (interface{}, error) {
+		return dataset.filterCaseCounts(from, to, country, states, interval, dateFormat)
+	})
+	counts, _ := result.(map[string]CountryWithStates)
+	return counts, err
 }
 
-// GetCountryCaseCountsWithDayData : get case counts for countries but without aggregating the counts, so a list of days with number of confirmed cases and deaths on each day is returned
-func GetCountryCaseCountsWithDayData(from string, to string, country string) (map[string]Country, error) {
-	if from == "" && to == "" && country == "" {
-		log.Println("GetCountryCaseCounts query for all data with per day information")
-		return countryCaseCountsMap, nil
+// GetCountryCaseCountsWithDayData : get case counts for countries but without aggregating the counts, so a list of days with number of confirmed cases and deaths on each day is returned.
+// If interval is a week or month, there is one item for each period instead of each day.
+func GetCountryCaseCountsWithDayData(from string, to string, country string, interval string, dateFormat string) (map[string]Country, error) {
+	dataset := getDataset()
+	log.Printf("GetCountryCaseCountsWithDayData query from: %s, to: %s, country: %s, interval: %s, dateFormat: %s\n", from, to, country, interval, dateFormat)
+	if !IsValidInterval(interval) {
+		return map[string]Country{}, fmt.Errorf("Interval %s is not supported", interval)
+	}
+	if !dateformat.IsValidOutputFormat(dateFormat) {
+		return map[string]Country{}, fmt.Errorf("Date format %s is not supported", dateFormat)
 	}
-	log.Printf("GetCountryCaseCountsWithDayData query from: %s, to: %s, country: %s\n", from, to, country)
-	filtered, err := filterCaseCounts(from, to, country)
-	return aggregateCountryDataFromCaseCounts(filtered), err
+	result, err := coalescer.do(dataset.getQueryKey("GetCountryCaseCountsWithDayData", from, to, country, interval, dateFormat), func() (interface{}, error) {
+		return dataset.filterCountryCaseCounts(from, to, country, interval, dateFormat)
+	})
+	counts, _ := result.(map[string]Country)
+	return counts, err
 }
 
-// GetCaseCounts : get case counts for all states between from date and to date. Return case counts for entire period if from and to dates are empty strings
-func GetCaseCounts(from string, to string, country string) (map[string]CountryWithStatesAggregated, error) {
-	if from == "" && to == "" && country == "" {
+// GetCaseCounts : get case counts for all states between from date and to date. Return case counts for entire period if from and to dates are empty strings.
+// If states is not empty, only the states with those names are returned
+func GetCaseCounts(from string, to string, country string, states []string) (map[string]CountryWithStatesAggregated, error) {
+	dataset := getDataset()
+	if from == "" && to == "" && country == "" && len(states) == 0 && dataset.isLoaded() {
 		log.Println("GetCaseCounts query for all data")
-		return stateAggregatedMap, nil
+		return dataset.stateAggregatedMap, nil
 	}
-	log.Printf("GetCaseCounts query from: %s, to: %s, country: %s\n", from, to, country)
-	return aggregateDataBetweenDates(from, to, country)
+	log.Printf("GetCaseCounts query from: %s, to: %s, country: %s, states: %v\n", from, to, country, states)
+	result, err := coalescer.do(dataset.getQueryKey("GetCaseCounts", from, to, country, strings.Join(states, ",")), func() (interface{}, error) {
+		return dataset.aggregateDataBetweenDates(from, to, country, states)
+	})
+	counts, _ := result.(map[string]CountryWithStatesAggregated)
+	return counts, err
 }
 
 // GetCountryCaseCounts : get case counts for all countries between from date and to date. Return case counts for entire period if from and to dates are empty strings
 func GetCountryCaseCounts(from string, to string, country string) (map[string]CountryAggregated, error) {
-	if from == "" && to == "" && country == "" {
+	dataset := getDataset()
+	if from == "" && to == "" && country == "" && dataset.isLoaded() {
 		log.Println("GetCountryCaseCounts query for all data")
-		return countryAggregatedMap, nil
+		return dataset.countryAggregatedMap, nil
 	}
 	log.Printf("GetCountryCaseCounts query from: %s, to: %s, country: %s\n", from, to, country)
-	agg, err := aggregateDataBetweenDates(from, to, country)
-	return aggregateCountryDataFromStatesAggregate(agg), err
+	result, err := coalescer.do(dataset.getQueryKey("GetCountryCaseCounts", from, to, country), func() (interface{}, error) {
+		return dataset.aggregateCountryDataBetweenDates(from, to, country)
+	})
+	counts, _ := result.(map[string]CountryAggregated)
+	return counts, err
 }
 
-// GetWorldCaseCounts : get case counts for the world.
-func GetWorldCaseCounts(from string, to string) ([]CaseCount, error) {
-	if from == "" && to == "" {
-		log.Println("GetWorldCaseCounts query for all data")
-		return worldCaseCountsCache, nil
+// GetWorldCaseCounts : get case counts for the world. If interval is a week or month, there is one item for each period instead of each day.
+func GetWorldCaseCounts(from string, to string, interval string, dateFormat string) ([]CaseCount, error) {
+	dataset := getDataset()
+	log.Printf("GetWorldCaseCounts query from: %s, to: %s, interval: %s, dateFormat: %s\n", from, to, interval, dateFormat)
+	if !IsValidInterval(interval) {
+		return nil, fmt.Errorf("Interval %s is not supported", interval)
+	}
+	if !dateformat.IsValidOutputFormat(dateFormat) {
+		return nil, fmt.Errorf("Date format %s is not supported", dateFormat)
 	}
-	log.Printf("GetWorldCaseCounts query from: %s, to: %s\n", from, to)
-	return getWorldDataBetweenDates(from, to)
+	result, err := coalescer.do(dataset.getQueryKey("GetWorldCaseCounts", from, to, interval, dateFormat), func() (interface{}, error) {
+		return dataset.getWorldDataBetweenDates(from, to, interval, dateFormat)
+	})
+	counts, _ := result.([]CaseCount)
+	return counts, err
+}
+
+// GetCountryMetadata : get the name, codes, location, population and range of dates with data of each country and its states, without any case counts.
+// Only the countries matching country are returned if it is not empty
+func GetCountryMetadata(country string, dateFormat string) (map[string]CountryMetadata, error) {
+	dataset := getDataset()
+	log.Printf("GetCountryMetadata query country: %s, dateFormat: %s\n", country, dateFormat)
+	if !dateformat.IsValidOutputFormat(dateFormat) {
+		return map[string]CountryMetadata{}, fmt.Errorf("Date format %s is not supported", dateFormat)
+	}
+	result, err := coalescer.do(dataset.getQueryKey("GetCountryMetadata", country, dateFormat), func() (interface{}, error) {
+		return dataset.getMetadata(country, dateFormat), nil
+	})
+	metadata, _ := result.(map[string]CountryMetadata)
+	return metadata, err
+}
+
+// IsLoaded : whether the case counts have been loaded since the server started
+func IsLoaded() bool {
+	return getDataset().isLoaded()
 }
 
-func setDateBoundariesAndAllAggregatedData(headerRow []string) {
-	firstDate, _ = time.Parse(dateformat.CasesDateFormat, headerRow[4])
-	lastDate, _ = time.Parse(dateformat.CasesDateFormat, headerRow[len(headerRow)-1])
-	stateAggregatedMap, _ = aggregateDataBetweenDates("", "", "")
-	countryAggregatedMap = aggregateCountryDataFromStatesAggregate(stateAggregatedMap)
-	countryCaseCountsMap = aggregateCountryDataFromCaseCounts(caseCountsMap)
-	worldCaseCountsCache = aggregateWorldData(countryCaseCountsMap)
+// GetLastDate : get the latest date that there is data for, which relative dates in queries count back from. Not ok if no data has been loaded yet
+func GetLastDate() (time.Time, bool) {
+	dataset := getDataset()
+	return dataset.lastDate, dataset.isLoaded()
 }
@@ -42,9 +42,9 @@ func getTestCacheData() map[string]CountryWithStates {
 				"": CaseCounts{
 					LocationAndPopulation{33.0, 65.1, 5000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{2, 2, 2}},
-						CaseCount{"1/23/20", statistics{3, 3, 3}},
-						CaseCount{"1/24/20", statistics{4, 4, 4}},
+						CaseCount{"1/22/20", statistics{2, 2, 2}, nil},
+						CaseCount{"1/23/20", statistics{3, 3, 3}, nil},
+						CaseCount{"1/24/20", statistics{4, 4, 4}, nil},
 					},
 				},
 			},
@@ -55,9 +55,9 @@ func getTestCacheData() map[string]CountryWithStates {
 				"": CaseCounts{
 					LocationAndPopulation{41.1533, 20.1683, 3000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{4, 4, 4}},
-						CaseCount{"1/23/20", statistics{5, 5, 5}},
-						CaseCount{"1/24/20", statistics{6, 6, 6}},
+						CaseCount{"1/22/20", statistics{4, 4, 4}, nil},
+						CaseCount{"1/23/20", statistics{5, 5, 5}, nil},
+						CaseCount{"1/24/20", statistics{6, 6, 6}, nil},
 					},
 				},
 			},
@@ -68,9 +68,9 @@ func getTestCacheData() map[string]CountryWithStates {
 				"": CaseCounts{
 					LocationAndPopulation{28.0339, 1.6596, 6000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{7, 7, 7}},
-						CaseCount{"1/23/20", statistics{8, 8, 8}},
-						CaseCount{"1/24/20", statistics{9, 9, 9}},
+						CaseCount{"1/22/20", statistics{7, 7, 7}, nil},
+						CaseCount{"1/23/20", statistics{8, 8, 8}, nil},
+						CaseCount{"1/24/20", statistics{9, 9, 9}, nil},
 					},
 				},
 			},
@@ -81,17 +81,17 @@ func getTestCacheData() map[string]CountryWithStates {
 				"": CaseCounts{
 					LocationAndPopulation{37.0902, -95.7129, 300000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{0, 0, 10}},
-						CaseCount{"1/23/20", statistics{0, 0, 11}},
-						CaseCount{"1/24/20", statistics{0, 0, 12}},
+						CaseCount{"1/22/20", statistics{0, 0, 10}, nil},
+						CaseCount{"1/23/20", statistics{0, 0, 11}, nil},
+						CaseCount{"1/24/20", statistics{0, 0, 12}, nil},
 					},
 				},
 				"American Samoa": CaseCounts{
 					LocationAndPopulation{-14.270999999999999, -170.132, 40000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{4, 1, 0}},
-						CaseCount{"1/23/20", statistics{5, 2, 0}},
-						CaseCount{"1/24/20", statistics{6, 3, 0}},
+						CaseCount{"1/22/20", statistics{4, 1, 0}, nil},
+						CaseCount{"1/23/20", statistics{5, 2, 0}, nil},
+						CaseCount{"1/24/20", statistics{6, 3, 0}, nil},
 					},
 				},
 			},
@@ -145,6 +145,15 @@ func setupTest() {
 			"London": 7000,
 		},
 	}
+	utils.Locations = utils.NewLocationLookup([][]string{
+		[]string{"4", "AF", "AFG", "4", "", "", "", "Afghanistan", "33.93911", "67.709953", "Afghanistan", "38928341"},
+		[]string{"8", "AL", "ALB", "8", "", "", "", "Albania", "41.1533", "20.1683", "Albania", "2877800"},
+		[]string{"12", "DZ", "DZA", "12", "", "", "", "Algeria", "28.0339", "1.6596", "Algeria", "43851043"},
+		[]string{"840", "US", "USA", "840", "", "", "", "US", "40", "-100", "US", "329466283"},
+		[]string{"156", "CN", "CHN", "156", "", "", "", "China", "35.8617", "104.1954", "China", "1404676330"},
+		[]string{"702", "SG", "SGP", "702", "", "", "", "Singapore", "1.2833", "103.8333", "Singapore", "5850343"},
+		[]string{"826", "GB", "GBR", "826", "", "", "", "United Kingdom", "55.3781", "-3.436", "United Kingdom", "67886004"},
+	})
 }
 
 func init() {
@@ -155,18 +164,20 @@ func TestUpdateCaseCounts(t *testing.T) {
 	client = &mockClient{}
 	clientGetCallCounter = 0
 	UpdateCaseCounts()
-	if firstDate.Format(dateformat.CasesDateFormat) != "1/22/20" {
-		t.Errorf("Value of firstDate is incorrect, got: %s, want %s.", firstDate, "1/22/20")
+	dataset := getDataset()
+	if dataset.firstDate.Format(dateformat.CasesDateFormat) != "1/22/20" {
+		t.Errorf("Value of firstDate is incorrect, got: %s, want %s.", dataset.firstDate, "1/22/20")
 	}
-	if lastDate.Format(dateformat.CasesDateFormat) != "1/24/20" {
-		t.Errorf("Value of lastDate is incorrect, got: %s, want %s.", lastDate, "1/24/20")
+	if dataset.lastDate.Format(dateformat.CasesDateFormat) != "1/24/20" {
+		t.Errorf("Value of lastDate is incorrect, got: %s, want %s.", dataset.lastDate, "1/24/20")
 	}
 	expectedCaseCounts := getTestCacheData()
 
-	if len(caseCountsMap) != 4 {
-		t.Errorf("Length of confirmedData is incorrect, got: %d, want %d.", len(caseCountsMap), 4)
+	caseCounts, _ := dataset.filterCaseCounts("", "", "", nil, "", "")
+	if len(caseCounts) != 4 {
+		t.Errorf("Length of confirmedData is incorrect, got: %d, want %d.", len(caseCounts), 4)
 	}
-	verifyResultsCaseCountsMap(caseCountsMap, expectedCaseCounts, t)
+	verifyResultsCaseCountsMap(caseCounts, expectedCaseCounts, t)
 
 	expectedAllAgg := map[string]CountryWithStatesAggregated{
 		"AF": CountryWithStatesAggregated{
@@ -210,7 +221,7 @@ func TestUpdateCaseCounts(t *testing.T) {
 			},
 		},
 	}
-	caseCountsAgg, _ := GetCaseCounts("", "", "")
+	caseCountsAgg, _ := GetCaseCounts("", "", "", nil)
 	verifyResultsCaseCountsAgg(caseCountsAgg, expectedAllAgg, t)
 
 	expectedAllCountryAgg := map[string]CountryAggregated{
@@ -246,28 +257,28 @@ func TestUpdateCaseCounts(t *testing.T) {
 	countryCaseCountsAgg, _ := GetCountryCaseCounts("", "", "")
 	verifyResultsCountryCaseCountsAgg(countryCaseCountsAgg, expectedAllCountryAgg, t)
 
-	caseCountsMap = nil
-	stateAggregatedMap = nil
-	countryAggregatedMap = nil
+	publishDataset(&Dataset{})
 }
 
 func UpdateCaseCountsSkipFaultyData(t *testing.T) {
 	client = &mockClient{}
 	clientGetCallCounter = 4
 	UpdateCaseCounts()
-	if firstDate.Format(dateformat.CasesDateFormat) != "1/22/20" {
-		t.Errorf("Value of firstDate is incorrect, got: %s, want %s.", firstDate, "1/22/20")
+	dataset := getDataset()
+	if dataset.firstDate.Format(dateformat.CasesDateFormat) != "1/22/20" {
+		t.Errorf("Value of firstDate is incorrect, got: %s, want %s.", dataset.firstDate, "1/22/20")
 	}
-	if lastDate.Format(dateformat.CasesDateFormat) != "1/24/20" {
-		t.Errorf("Value of lastDate is incorrect, got: %s, want %s.", lastDate, "1/24/20")
+	if dataset.lastDate.Format(dateformat.CasesDateFormat) != "1/24/20" {
+		t.Errorf("Value of lastDate is incorrect, got: %s, want %s.", dataset.lastDate, "1/24/20")
 	}
 	expectedCaseCounts := getTestCacheData()
 	delete(expectedCaseCounts, "AF")
 
-	if len(caseCountsMap) != 3 {
-		t.Errorf("Length of confirmedData is incorrect, got: %d, want %d.", len(caseCountsMap), 3)
+	caseCounts, _ := dataset.filterCaseCounts("", "", "", nil, "", "")
+	if len(caseCounts) != 3 {
+		t.Errorf("Length of confirmedData is incorrect, got: %d, want %d.", len(caseCounts), 3)
 	}
-	verifyResultsCaseCountsMap(caseCountsMap, expectedCaseCounts, t)
+	verifyResultsCaseCountsMap(caseCounts, expectedCaseCounts, t)
 }
 
 func TestGetCounts(t *testing.T) {
@@ -317,7 +328,7 @@ func TestGetCounts(t *testing.T) {
 			},
 		},
 	}
-	caseCountsAgg, _ := GetCaseCounts("1/23/20", "1/24/20", "")
+	caseCountsAgg, _ := GetCaseCounts("1/23/20", "1/24/20", "", nil)
 	verifyResultsCaseCountsAgg(caseCountsAgg, expectedQueryAgg, t)
 
 	expectedQueryCountryAgg := map[string]CountryAggregated{
@@ -353,9 +364,7 @@ func TestGetCounts(t *testing.T) {
 	countryCaseCountsAgg, _ := GetCountryCaseCounts("1/22/20", "1/23/20", "")
 	verifyResultsCountryCaseCountsAgg(countryCaseCountsAgg, expectedQueryCountryAgg, t)
 
-	caseCountsMap = nil
-	stateAggregatedMap = nil
-	countryAggregatedMap = nil
+	publishDataset(&Dataset{})
 }
 
 func TestGetDaysBetweenDates(t *testing.T) {
@@ -379,10 +388,10 @@ func TestGetDaysBetweenDates(t *testing.T) {
 }
 
 func TestGetStatisticsSum(t *testing.T) {
-	var input = []CaseCount{
-		CaseCount{"a", statistics{2, 1, 0}},
-		CaseCount{"b", statistics{4, 2, 1}},
-		CaseCount{"c", statistics{7, 5, 3}},
+	input := timeSeries{
+		confirmed: []int32{2, 4, 7},
+		deaths:    []int32{1, 2, 5},
+		recovered: []int32{0, 1, 3},
 	}
 
 	tables := []struct {
@@ -401,15 +410,15 @@ func TestGetStatisticsSum(t *testing.T) {
 	}
 
 	for _, table := range tables {
-		confirmed, deaths, recovered := getStatisticsSum(input, table.fromIndex, table.toIndex)
-		if confirmed != table.expectedComfirmed {
-			t.Errorf("Confirmed was not correct, got: %d, want %d.", confirmed, table.expectedComfirmed)
+		result := input.getStatisticsSum(table.fromIndex, table.toIndex)
+		if result.Confirmed != table.expectedComfirmed {
+			t.Errorf("Confirmed was not correct, got: %d, want %d.", result.Confirmed, table.expectedComfirmed)
 		}
-		if deaths != table.expectedDeaths {
-			t.Errorf("Deaths was not 0, got: %d, want %d.", deaths, table.expectedDeaths)
+		if result.Deaths != table.expectedDeaths {
+			t.Errorf("Deaths was not 0, got: %d, want %d.", result.Deaths, table.expectedDeaths)
 		}
-		if recovered != table.expectedRecovered {
-			t.Errorf("Deaths was not 0, got: %d, want %d.", recovered, table.expectedRecovered)
+		if result.Recovered != table.expectedRecovered {
+			t.Errorf("Deaths was not 0, got: %d, want %d.", result.Recovered, table.expectedRecovered)
 		}
 	}
 }
@@ -0,0 +1,65 @@
+package casecount
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// inFlightQuery : a computation that is currently running, which other callers with the same key can wait on
+type inFlightQuery struct {
+	wg     sync.WaitGroup
+	result interface{}
+	err    error
+}
+
+// queryCoalescer : collapses identical concurrent queries so that only one computation runs and all waiters share the result
+type queryCoalescer struct {
+	mux     sync.Mutex
+	queries map[string]*inFlightQuery
+}
+
+var coalescer = &queryCoalescer{queries: make(map[string]*inFlightQuery)}
+
+// getQueryKey : key identifying the query on this dataset, so that queries on different datasets are never coalesced
+func (d *Dataset) getQueryKey(parts ...string) string {
+	return strconv.FormatUint(d.version, 10) + "|" + strings.Join(parts, "|")
+}
+
+// do : run computeFn for the key, or wait for the already running computation with the same key and return its result.
+// The result is shared between all the callers, so it must not be modified.
+func (c *queryCoalescer) do(key string, computeFn func() (interface{}, error)) (interface{}, error) {
+	c.mux.Lock()
+	if query, ok := c.queries[key]; ok {
+		c.mux.Unlock()
+		query.wg.Wait()
+		return query.result, query.err
+	}
+	query := &inFlightQuery{}
+	query.wg.Add(1)
+	c.queries[key] = query
+	c.mux.Unlock()
+
+	defer func() {
+		c.mux.Lock()
+		delete(c.queries, key)
+		c.mux.Unlock()
+		query.wg.Done()
+	}()
+	query.result, query.err = compute(computeFn)
+	return query.result, query.err
+}
+
+// compute : run computeFn, and return an error if it panics so that the callers waiting on it are still released with a result
+func compute(computeFn func() (interface{}, error)) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Query panicked: %v\n%s", r, debug.Stack())
+			result, err = nil, fmt.Errorf("Query failed: %v", r)
+		}
+	}()
+	return computeFn()
+}
@@ -0,0 +1,112 @@
+package casecount
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQueryCoalescer_IdenticalQueriesComputeOnce(t *testing.T) {
+	c := &queryCoalescer{queries: make(map[string]*inFlightQuery)}
+	var computeCount int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	computeFn := func() (interface{}, error) {
+		atomic.AddInt32(&computeCount, 1)
+		close(started)
+		<-release
+		return "result", nil
+	}
+
+	numCallers := 10
+	results := make(chan interface{}, numCallers)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		result, _ := c.do("key", computeFn)
+		results <- result
+	}()
+	<-started
+	for i := 1; i < numCallers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, _ := c.do("key", computeFn)
+			results <- result
+		}()
+	}
+	// give the other callers time to start waiting on the in flight query
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if computeCount != 1 {
+		t.Errorf("computeFn was called an incorrect number of times, got: %d, want: %d.", computeCount, 1)
+	}
+	for result := range results {
+		if result != "result" {
+			t.Errorf("Result is incorrect, got: %v, want: %s.", result, "result")
+		}
+	}
+	if len(c.queries) != 0 {
+		t.Errorf("In flight queries should have been cleared, got: %d, want: %d.", len(c.queries), 0)
+	}
+}
+
+func TestQueryCoalescer_DifferentKeysComputeSeparately(t *testing.T) {
+	c := &queryCoalescer{queries: make(map[string]*inFlightQuery)}
+	first, _ := c.do("first", func() (interface{}, error) { return 1, nil })
+	second, _ := c.do("second", func() (interface{}, error) { return 2, nil })
+	if first != 1 || second != 2 {
+		t.Errorf("Results are incorrect, got: %v and %v, want: %d and %d.", first, second, 1, 2)
+	}
+}
+
+func TestQueryCoalescer_ErrorIsShared(t *testing.T) {
+	c := &queryCoalescer{queries: make(map[string]*inFlightQuery)}
+	expected := errors.New("test failure")
+	_, err := c.do("key", func() (interface{}, error) { return nil, expected })
+	if err != expected {
+		t.Errorf("Error is incorrect, got: %v, want: %v.", err, expected)
+	}
+	result, err := c.do("key", func() (interface{}, error) { return "recomputed", nil })
+	if err != nil || result != "recomputed" {
+		t.Errorf("Query should be recomputed after the previous one finished, got: %v, %v.", result, err)
+	}
+}
+
+func TestQueryCoalescer_PanicIsReturnedAsError(t *testing.T) {
+	c := &queryCoalescer{queries: make(map[string]*inFlightQuery)}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	errs := make(chan error, 2)
+	go func() {
+		_, err := c.do("key", func() (interface{}, error) {
+			close(started)
+			<-release
+			panic("test panic")
+		})
+		errs <- err
+	}()
+	<-started
+	go func() {
+		_, err := c.do("key", func() (interface{}, error) { return "not coalesced", nil })
+		errs <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err == nil || !strings.Contains(err.Error(), "test panic") {
+			t.Errorf("Error of a query that panicked is incorrect, got: %v, want error containing: %s.", err, "test panic")
+		}
+	}
+	result, err := c.do("key", func() (interface{}, error) { return "recomputed", nil })
+	if err != nil || result != "recomputed" {
+		t.Errorf("Query should be recomputed after the previous one panicked, got: %v, %v.", result, err)
+	}
+}
@@ -0,0 +1,81 @@
+package casecount
+
+import (
+	"sync/atomic"
+	"time"
+
+	"yet-another-covid-map-api/dateformat"
+)
+
+// Dataset : immutable snapshot of all the ingested case count data together with the aggregates that are precomputed from it.
+// A new Dataset is built on every update and published atomically, so a query that holds on to a Dataset always sees consistent data.
+// The counts are stored as columns sharing a single date axis, and are only converted to the response types when a query is made.
+type Dataset struct {
+	version   uint64
+	firstDate time.Time
+	lastDate  time.Time
+	// dates : the date axis formatted in each of dateformat.OutputFormats
+	dates map[string][]string
+
+	countries map[string]*countrySeries
+	world     timeSeries
+
+	// cache the query for getting all data for all states and all countries, because it is the most heavily used
+	stateAggregatedMap   map[string]CountryWithStatesAggregated
+	countryAggregatedMap map[string]CountryAggregated
+}
+
+var (
+	currentDataset atomic.Value
+	datasetVersion uint64
+)
+
+func init() {
+	currentDataset.Store(&Dataset{})
+}
+
+// newDataset : build a dataset from the per state time series, precomputing the country and world totals and the aggregates for the entire period
+func newDataset(countries map[string]*countrySeries, headerRow []string) *Dataset {
+	dataset := &Dataset{version: atomic.AddUint64(&datasetVersion, 1), countries: countries}
+	dataset.firstDate, _ = time.Parse(dateformat.CasesDateFormat, headerRow[4])
+	dataset.lastDate, _ = time.Parse(dateformat.CasesDateFormat, headerRow[len(headerRow)-1])
+	numDays := len(headerRow) - 4
+	dataset.dates = make(map[string][]string, len(dateformat.OutputFormats))
+	for _, outputFormat := range dateformat.OutputFormats {
+		dates := make([]string, numDays)
+		for i := range dates {
+			dates[i] = dateformat.FormatOutputDate(outputFormat, dataset.firstDate.AddDate(0, 0, i))
+		}
+		dataset.dates[outputFormat] = dates
+	}
+	for _, countryInfo := range countries {
+		countryInfo.total = getCountryTotal(countryInfo.states)
+	}
+	dataset.world = getWorldTotal(countries, numDays)
+	dataset.stateAggregatedMap, _ = dataset.aggregateDataBetweenDates("", "", "", nil)
+	dataset.countryAggregatedMap, _ = dataset.aggregateCountryDataBetweenDates("", "", "")
+	return dataset
+}
+
+// publishDataset : atomically replace the dataset used by all queries started from now on
+func publishDataset(dataset *Dataset) {
+	currentDataset.Store(dataset)
+}
+
+// isLoaded : whether the dataset contains any data, which is false until the first successful update
+func (d *Dataset) isLoaded() bool {
+	return d.countries != nil
+}
+
+// getDates : get the date axis formatted in outputFormat, which is the John Hopkins CSSE format if it is empty
+func (d *Dataset) getDates(outputFormat string) []string {
+	if outputFormat == "" {
+		return d.dates[dateformat.FormatJHU]
+	}
+	return d.dates[outputFormat]
+}
+
+// getDataset : get the snapshot of the most recently published dataset, which must not be modified
+func getDataset() *Dataset {
+	return currentDataset.Load().(*Dataset)
+}
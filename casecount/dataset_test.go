@@ -0,0 +1,106 @@
+package casecount
+
+import (
+	"sync"
+	"testing"
+)
+
+func getScaledTestCaseCounts(factor int) map[string]CountryWithStates {
+	result := getTestCaseCounts()
+	for _, countryInfo := range result {
+		for _, stateInfo := range countryInfo.States {
+			for i := range stateInfo.Counts {
+				stateInfo.Counts[i].Confirmed *= factor
+				stateInfo.Counts[i].Deaths *= factor
+				stateInfo.Counts[i].Recovered *= factor
+			}
+		}
+	}
+	return result
+}
+
+func TestGetDataset_BeforeFirstUpdate(t *testing.T) {
+	publishDataset(&Dataset{})
+	result, err := GetWorldCaseCounts("1/23/20", "1/26/20", "", "")
+	if err != nil || len(result) != 0 {
+		t.Errorf("Query on an empty dataset should return no data, got: %+v, %v.", result, err)
+	}
+	caseCounts, err := GetCaseCounts("1/23/20", "1/26/20", "SG", nil)
+	if err != nil || len(caseCounts) != 0 {
+		t.Errorf("Query on an empty dataset should return no data, got: %+v, %v.", caseCounts, err)
+	}
+}
+
+func TestPublishDataset_IncrementsVersion(t *testing.T) {
+	first := newDataset(toCountrySeriesMap(getTestCaseCounts()), testHeaderRow)
+	second := newDataset(toCountrySeriesMap(getTestCaseCounts()), testHeaderRow)
+	publishDataset(first)
+	publishDataset(second)
+	if getDataset() != second {
+		t.Error("getDataset should return the most recently published dataset.")
+	}
+	if second.version <= first.version {
+		t.Errorf("Dataset version should increase, got: %d after %d.", second.version, first.version)
+	}
+}
+
+// run with -race to verify that swapping the dataset while queries are running does not race,
+// and that every query result comes from a single snapshot instead of a mix of old and new data
+func TestPublishDataset_ConcurrentQueriesSeeSingleSnapshot(t *testing.T) {
+	datasets := []*Dataset{
+		newDataset(toCountrySeriesMap(getScaledTestCaseCounts(1)), testHeaderRow),
+		newDataset(toCountrySeriesMap(getScaledTestCaseCounts(10)), testHeaderRow),
+	}
+	publishDataset(datasets[0])
+
+	done := make(chan struct{})
+	swapperWg := sync.WaitGroup{}
+	swapperWg.Add(1)
+	go func() {
+		defer swapperWg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				publishDataset(datasets[i%2])
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				world, err := GetWorldCaseCounts("1/23/20", "1/26/20", "", "")
+				if err != nil || len(world) != 4 {
+					t.Errorf("World query result is incorrect, got: %+v, %v.", world, err)
+					return
+				}
+				expectedConfirmed := []int{1254, 2703, 3185, 3655}
+				factor := world[0].Confirmed / expectedConfirmed[0]
+				for k, count := range world {
+					if count.Confirmed != expectedConfirmed[k]*factor {
+						t.Errorf("World query result mixes data from different datasets, got: %+v.", world)
+						return
+					}
+				}
+				countries, err := GetCountryCaseCounts("1/24/20", "1/26/20", "")
+				if err != nil {
+					t.Errorf("Country query returned an error: %s.", err.Error())
+					return
+				}
+				factor = countries["SG"].Confirmed / 12
+				if countries["CN"].Confirmed != 2375*factor || countries["GB"].Confirmed != 14*factor {
+					t.Errorf("Country query result mixes data from different datasets, got: %+v.", countries)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(done)
+	swapperWg.Wait()
+}
@@ -0,0 +1,81 @@
+package casecount
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// IntervalDay : one entry per day, which is the default
+	IntervalDay = "day"
+	// IntervalWeek : one entry per ISO 8601 week, which starts on Monday
+	IntervalWeek = "week"
+	// IntervalMonth : one entry per calendar month
+	IntervalMonth = "month"
+	// IntervalEpiWeek : one entry per CDC MMWR epidemiological week, which starts on Sunday
+	IntervalEpiWeek = "epiweek"
+)
+
+// Intervals : all the supported intervals for per day queries
+var Intervals = []string{IntervalDay, IntervalWeek, IntervalMonth, IntervalEpiWeek}
+
+// period : days between fromIndex and toIndex inclusive on the date axis that are reported as a single entry
+type period struct {
+	label     string
+	fromIndex int
+	toIndex   int
+}
+
+// IsValidInterval : whether the interval is supported, an empty interval means IntervalDay
+func IsValidInterval(interval string) bool {
+	if interval == "" {
+		return true
+	}
+	for _, validInterval := range Intervals {
+		if interval == validInterval {
+			return true
+		}
+	}
+	return false
+}
+
+// getWeekYearAndNumber : get the week based year and week number of date, for weeks starting on firstWeekday.
+// Week 1 is the first week with at least four days in the year, which is how both ISO 8601 and CDC MMWR weeks are defined.
+func getWeekYearAndNumber(date time.Time, firstWeekday time.Weekday) (int, int) {
+	weekStart := date.AddDate(0, 0, -((int(date.Weekday()) - int(firstWeekday) + 7) % 7))
+	// the week belongs to the year that its fourth day is in
+	year := weekStart.AddDate(0, 0, 3).Year()
+	jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.UTC)
+	firstWeekStart := jan4.AddDate(0, 0, -((int(jan4.Weekday()) - int(firstWeekday) + 7) % 7))
+	return year, getDaysBetweenDates(firstWeekStart, weekStart)/7 + 1
+}
+
+// getPeriodLabel : label of the period that date is in, such as 2020-W04 or 2020-01, and an empty label for days
+func getPeriodLabel(date time.Time, interval string) string {
+	switch interval {
+	case IntervalWeek:
+		year, week := getWeekYearAndNumber(date, time.Monday)
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case IntervalEpiWeek:
+		year, week := getWeekYearAndNumber(date, time.Sunday)
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case IntervalMonth:
+		return date.Format("2006-01")
+	}
+	return ""
+}
+
+// getPeriods : split the days between fromIndex and toIndex into periods of the interval.
+// The first and last periods only contain the days in the range, so they can be shorter than a full week or month.
+func (d *Dataset) getPeriods(fromIndex int, toIndex int, interval string) []period {
+	var periods []period
+	for index := fromIndex; index <= toIndex; index++ {
+		label := getPeriodLabel(d.firstDate.AddDate(0, 0, index), interval)
+		if len(periods) > 0 && label != "" && periods[len(periods)-1].label == label {
+			periods[len(periods)-1].toIndex = index
+		} else {
+			periods = append(periods, period{label, index, index})
+		}
+	}
+	return periods
+}
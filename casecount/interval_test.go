@@ -0,0 +1,142 @@
+package casecount
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetPeriodLabel(t *testing.T) {
+	tables := []struct {
+		date     string
+		interval string
+		expected string
+	}{
+		{"2020-01-22", IntervalDay, ""},
+		{"2020-01-22", IntervalMonth, "2020-01"},
+		{"2020-01-22", IntervalWeek, "2020-W04"},
+		{"2020-01-22", IntervalEpiWeek, "2020-W04"},
+		{"2019-12-30", IntervalWeek, "2020-W01"},
+		{"2019-12-29", IntervalWeek, "2019-W52"},
+		{"2020-12-31", IntervalWeek, "2020-W53"},
+		{"2021-01-03", IntervalWeek, "2020-W53"},
+		{"2021-01-04", IntervalWeek, "2021-W01"},
+		{"2019-12-29", IntervalEpiWeek, "2020-W01"},
+		{"2019-12-28", IntervalEpiWeek, "2019-W52"},
+		{"2021-01-02", IntervalEpiWeek, "2020-W53"},
+		{"2021-01-03", IntervalEpiWeek, "2021-W01"},
+		{"2022-01-01", IntervalEpiWeek, "2021-W52"},
+		{"2022-01-02", IntervalEpiWeek, "2022-W01"},
+	}
+	for _, table := range tables {
+		date, _ := time.Parse("2006-01-02", table.date)
+		if label := getPeriodLabel(date, table.interval); label != table.expected {
+			t.Errorf("Period label of %s for interval %s is incorrect, got: %s, want: %s.", table.date, table.interval, label, table.expected)
+		}
+	}
+}
+
+func TestIsValidInterval(t *testing.T) {
+	tables := []struct {
+		interval string
+		expected bool
+	}{
+		{"", true},
+		{IntervalDay, true},
+		{IntervalWeek, true},
+		{IntervalMonth, true},
+		{IntervalEpiWeek, true},
+		{"fortnight", false},
+	}
+	for _, table := range tables {
+		if valid := IsValidInterval(table.interval); valid != table.expected {
+			t.Errorf("IsValidInterval result for %s is incorrect, got: %t, want: %t.", table.interval, valid, table.expected)
+		}
+	}
+}
+
+func TestGetPeriods_Week(t *testing.T) {
+	// 1/22/20 is a Wednesday, so the ISO week changes on Monday 1/27/20 and the epi week changes on Sunday 1/26/20
+	dataset := publishTestDataset()
+	tables := []struct {
+		interval string
+		expected []period
+	}{
+		{IntervalDay, []period{period{"", 1, 1}, period{"", 2, 2}, period{"", 3, 3}, period{"", 4, 4}, period{"", 5, 5}}},
+		{IntervalWeek, []period{period{"2020-W04", 1, 4}, period{"2020-W05", 5, 5}}},
+		{IntervalEpiWeek, []period{period{"2020-W04", 1, 3}, period{"2020-W05", 4, 5}}},
+		{IntervalMonth, []period{period{"2020-01", 1, 5}}},
+	}
+	for _, table := range tables {
+		periods := dataset.getPeriods(1, 5, table.interval)
+		if len(periods) != len(table.expected) {
+			t.Errorf("Number of periods for interval %s is incorrect, got: %d, want: %d.", table.interval, len(periods), len(table.expected))
+			continue
+		}
+		for i, p := range periods {
+			if p != table.expected[i] {
+				t.Errorf("Period for interval %s is incorrect, got: %+v, want: %+v.", table.interval, p, table.expected[i])
+			}
+		}
+	}
+}
+
+func TestWorldTotal_Week(t *testing.T) {
+	publishTestDataset()
+	result, _ := GetWorldCaseCounts("", "", IntervalWeek, "")
+	expectedData := []CaseCount{
+		CaseCount{"1/26/20", statistics{3655, 376, 426}, &Period{"2020-W04", statistics{3655, 376, 426}}},
+		CaseCount{"1/27/20", statistics{3929, 456, 576}, &Period{"2020-W05", statistics{274, 80, 150}}},
+	}
+	if len(result) != len(expectedData) {
+		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), len(expectedData))
+	}
+	verifyResultsCaseCountArr(expectedData, result, t)
+}
+
+func TestWorldTotal_EpiWeekQueryDates(t *testing.T) {
+	publishTestDataset()
+	result, _ := GetWorldCaseCounts("1/23/20", "1/27/20", IntervalEpiWeek, "")
+	expectedData := []CaseCount{
+		CaseCount{"1/25/20", statistics{3185, 328, 299}, &Period{"2020-W04", statistics{3023, 293, 299}}},
+		CaseCount{"1/27/20", statistics{3929, 456, 576}, &Period{"2020-W05", statistics{744, 128, 277}}},
+	}
+	if len(result) != len(expectedData) {
+		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), len(expectedData))
+	}
+	verifyResultsCaseCountArr(expectedData, result, t)
+}
+
+func TestCaseCountsWithDayData_Month(t *testing.T) {
+	publishTestDataset()
+	result, _ := GetCaseCountsWithDayData("1/23/20", "", "CN", nil, IntervalMonth, "")
+	expectedData := map[string]CountryWithStates{
+		"CN": CountryWithStates{
+			Name: "China",
+			States: map[string]CaseCounts{
+				"Beijing": CaseCounts{
+					LocationAndPopulation{40.1824, 116.4142, 50000},
+					[]CaseCount{CaseCount{"1/27/20", statistics{1235, 152, 90}, &Period{"2020-01", statistics{1185, 142, 90}}}},
+				},
+				"Hubei": CaseCounts{
+					LocationAndPopulation{30.9756, 112.2707, 30000},
+					[]CaseCount{CaseCount{"1/27/20", statistics{2111, 230, 460}, &Period{"2020-01", statistics{2011, 210, 460}}}},
+				},
+				"Shanghai": CaseCounts{
+					LocationAndPopulation{31.202, 121.4491, 40000},
+					[]CaseCount{CaseCount{"1/27/20", statistics{532, 55, 10}, &Period{"2020-01", statistics{522, 50, 10}}}},
+				},
+			},
+		},
+	}
+	if len(result) != 1 {
+		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), 1)
+	}
+	verifyResultsCaseCountsMap(expectedData, result, t)
+}
+
+func TestCaseCountsWithDayData_InvalidInterval(t *testing.T) {
+	publishTestDataset()
+	if _, err := GetCountryCaseCountsWithDayData("", "", "", "fortnight", ""); err == nil {
+		t.Error("Error message should be returned.")
+	}
+}
@@ -0,0 +1,60 @@
+package casecount
+
+import (
+	"yet-another-covid-map-api/utils"
+)
+
+// getFirstDataIndex : get the index of the first day with any confirmed cases, deaths or recoveries, not ok if there are none
+func (s timeSeries) getFirstDataIndex() (int, bool) {
+	for i := range s.confirmed {
+		if s.confirmed[i] > 0 || s.deaths[i] > 0 || s.recovered[i] > 0 {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// getDateRange : get the range from the first day that the series has data for until the last day of the dataset, nil if there is no data at all
+func (s timeSeries) getDateRange(dates []string) *DateRange {
+	firstIndex, ok := s.getFirstDataIndex()
+	if !ok {
+		return nil
+	}
+	return &DateRange{dates[firstIndex], dates[len(dates)-1]}
+}
+
+func (s *locationSeries) toStateMetadata(iso string, state string, dates []string) StateMetadata {
+	metadata := StateMetadata{LocationAndPopulation: s.LocationAndPopulation, DateRange: s.getDateRange(dates)}
+	if location, ok := utils.Locations.GetState(iso, state); ok {
+		metadata.UID, metadata.ISO2, metadata.FIPS = location.UID, location.ISO2, location.FIPS
+	}
+	return metadata
+}
+
+// toCountryMetadata : get the metadata of the country with iso2 and of its states. The row of the country itself, which has an empty state, is not a state
+func (c *countrySeries) toCountryMetadata(iso string, dates []string) CountryMetadata {
+	metadata := CountryMetadata{Name: c.name, ISO2: iso, LocationAndPopulation: c.total.LocationAndPopulation,
+		DateRange: c.total.getDateRange(dates), States: make(map[string]StateMetadata, len(c.states))}
+	if location, ok := utils.Locations.GetCountry(iso); ok {
+		metadata.ISO3, metadata.Code3, metadata.UID = location.ISO3, location.Code3, location.UID
+	}
+	for state, stateInfo := range c.states {
+		if state != "" {
+			metadata.States[state] = stateInfo.toStateMetadata(iso, state, dates)
+		}
+	}
+	return metadata
+}
+
+// getMetadata : get the metadata of the countries matching country, or of all countries if it is empty
+func (d *Dataset) getMetadata(country string, dateFormat string) map[string]CountryMetadata {
+	metadata := make(map[string]CountryMetadata)
+	if !d.isLoaded() {
+		return metadata
+	}
+	dates := d.getDates(dateFormat)
+	for _, iso := range d.selectCountries(country) {
+		metadata[iso] = d.countries[iso].toCountryMetadata(iso, dates)
+	}
+	return metadata
+}
@@ -0,0 +1,95 @@
+package casecount
+
+import (
+	"testing"
+
+	"yet-another-covid-map-api/dateformat"
+	"yet-another-covid-map-api/utils"
+)
+
+func setTestLocations(t *testing.T) {
+	locations := utils.Locations
+	utils.Locations = utils.NewLocationLookup([][]string{
+		[]string{"156", "CN", "CHN", "156", "", "", "", "China", "35.8617", "104.1954", "China", "1404676330"},
+		[]string{"15617", "CN", "CHN", "156", "", "", "Hubei", "China", "30.9756", "112.2707", "Hubei, China", "57752557"},
+		[]string{"702", "SG", "SGP", "702", "", "", "", "Singapore", "1.2833", "103.8333", "Singapore", "5850343"},
+	})
+	t.Cleanup(func() {
+		utils.Locations = locations
+	})
+}
+
+func TestTimeSeries_GetDateRange(t *testing.T) {
+	dates := []string{"1/22/20", "1/23/20", "1/24/20"}
+	tables := []struct {
+		series   timeSeries
+		expected *DateRange
+	}{
+		{timeSeries{[]int32{1, 2, 3}, []int32{0, 0, 0}, []int32{0, 0, 0}}, &DateRange{"1/22/20", "1/24/20"}},
+		{timeSeries{[]int32{0, 0, 3}, []int32{0, 1, 1}, []int32{0, 0, 0}}, &DateRange{"1/23/20", "1/24/20"}},
+		{timeSeries{[]int32{0, 0, 0}, []int32{0, 0, 0}, []int32{0, 0, 2}}, &DateRange{"1/24/20", "1/24/20"}},
+		{newTimeSeries(3), nil},
+	}
+	for _, table := range tables {
+		result := table.series.getDateRange(dates)
+		if (result == nil) != (table.expected == nil) || (result != nil && *result != *table.expected) {
+			t.Errorf("Date range is incorrect for %+v, got: %+v, want: %+v.", table.series, result, table.expected)
+		}
+	}
+}
+
+func TestGetCountryMetadata_AllCountries(t *testing.T) {
+	setTestLocations(t)
+	publishTestDataset()
+	result, err := GetCountryMetadata("", "")
+	if err != nil {
+		t.Fatalf("Error should not be returned, got: %s.", err.Error())
+	}
+	if len(result) != 3 {
+		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), 3)
+	}
+	china := result["CN"]
+	if china.Name != "China" || china.ISO2 != "CN" || china.ISO3 != "CHN" || china.Code3 != "156" || china.UID != "156" {
+		t.Errorf("Codes of China are incorrect, got: %+v.", china)
+	}
+	if china.Population != 120000 || china.DateRange == nil || *china.DateRange != (DateRange{"1/22/20", "1/27/20"}) {
+		t.Errorf("Population or date range of China is incorrect, got: %+v.", china)
+	}
+	if len(china.States) != 3 {
+		t.Errorf("Number of states of China is incorrect, got: %d, want: %d.", len(china.States), 3)
+	}
+	hubei := china.States["Hubei"]
+	expectedHubei := StateMetadata{"15617", "CN", "", LocationAndPopulation{30.9756, 112.2707, 30000}, &DateRange{"1/22/20", "1/27/20"}}
+	if hubei.UID != expectedHubei.UID || hubei.ISO2 != expectedHubei.ISO2 || hubei.LocationAndPopulation != expectedHubei.LocationAndPopulation ||
+		hubei.DateRange == nil || *hubei.DateRange != *expectedHubei.DateRange {
+		t.Errorf("Metadata of Hubei is incorrect, got: %+v, want: %+v.", hubei, expectedHubei)
+	}
+	if beijing := china.States["Beijing"]; beijing.UID != "" || beijing.Population != 50000 {
+		t.Errorf("Metadata of Beijing is incorrect, got: %+v.", beijing)
+	}
+	if singapore := result["SG"]; len(singapore.States) != 0 || singapore.ISO3 != "SGP" || singapore.Population != 6000 {
+		t.Errorf("Metadata of Singapore is incorrect, the country row should not be a state, got: %+v.", singapore)
+	}
+}
+
+func TestGetCountryMetadata_Country(t *testing.T) {
+	setTestLocations(t)
+	publishTestDataset()
+	result, _ := GetCountryMetadata("SG", dateformat.FormatISO)
+	if len(result) != 1 {
+		t.Fatalf("Result should only contain Singapore, got: %+v.", result)
+	}
+	if dateRange := result["SG"].DateRange; dateRange == nil || *dateRange != (DateRange{"2020-01-22", "2020-01-27"}) {
+		t.Errorf("Date range is incorrect, got: %+v, want: %+v.", dateRange, DateRange{"2020-01-22", "2020-01-27"})
+	}
+	if result, _ := GetCountryMetadata("XX", ""); len(result) != 0 {
+		t.Errorf("Result should be empty for an unknown country, got: %+v.", result)
+	}
+}
+
+func TestGetCountryMetadata_InvalidDateFormat(t *testing.T) {
+	publishTestDataset()
+	if _, err := GetCountryMetadata("", dateformat.FormatYearFirst); err == nil {
+		t.Error("Error message should be returned.")
+	}
+}
@@ -1,167 +1,286 @@
 package casecount
 
 import (
+	"fmt"
 	"log"
 	"strconv"
-	"sync"
+
 	"yet-another-covid-map-api/utils"
 )
 
+const (
+	globalCountsStartIndex   = 4
+	usCountsStartIndex       = 11
+	usDeathsCountsStartIndex = 12
+)
+
 type extractedInformation struct {
 	state   string
 	country string
-	counts  CaseCounts
-}
-
-func extractCaseCounts(headerRow []string, confirmedData [][]string, deathsData [][]string, recoveredData [][]string) map[string]CountryWithStates {
-	caseCountsMap := make(map[string]CountryWithStates)
-	numRows := len(confirmedData)
-	ch := make(chan extractedInformation, numRows-1)
-	recoveredCh := make(chan extractedInformation, len(recoveredData)-1)
-	wg := sync.WaitGroup{}
-	for rowIndex := 1; rowIndex < numRows; rowIndex++ {
-		wg.Add(1)
-		go getCaseCountsData(headerRow, confirmedData[rowIndex], deathsData[rowIndex], nil, confirmedData[rowIndex][0:4], ch, &wg)
-	}
-	for rowIndex := 1; rowIndex < len(recoveredData); rowIndex++ {
-		wg.Add(1)
-		go getCaseCountsData(headerRow, nil, nil, recoveredData[rowIndex], recoveredData[rowIndex][0:4], recoveredCh, &wg)
-	}
-	wg.Wait()
-	close(ch)
-	close(recoveredCh)
-	for item := range ch {
-		if _, ok := caseCountsMap[item.country]; !ok {
-			countryName, _ := utils.GetCountryFromAbbreviation(item.country)
-			countryInfo := CountryWithStates{countryName, map[string]CaseCounts{}}
-			caseCountsMap[item.country] = countryInfo
-		}
-		caseCountsMap[item.country].States[item.state] = item.counts
+	series  *locationSeries
+}
+
+// usCounty : a row of the US CSV files, which are summed up into their states once both files have been read
+type usCounty struct {
+	state    string
+	location LocationAndPopulation
+	series   timeSeries
+}
+
+// ingestion : the state of an update while the CSV files are streamed in row by row.
+// Faulty values cause the row to be skipped, while structural problems such as mismatched files abort the update.
+type ingestion struct {
+	headerRow  []string
+	numDays    int
+	globalRows []*extractedInformation
+	countries  map[string]*countrySeries
+	usCounties []*usCounty
+}
+
+func newIngestion() *ingestion {
+	return &ingestion{countries: make(map[string]*countrySeries)}
+}
+
+func getOrCreateCountrySeries(countries map[string]*countrySeries, iso string) *countrySeries {
+	if _, ok := countries[iso]; !ok {
+		countryName, _ := utils.GetCountryFromAbbreviation(iso)
+		countries[iso] = &countrySeries{name: countryName, states: map[string]*locationSeries{}}
 	}
-	for item := range recoveredCh {
-		if state, ok := caseCountsMap[item.country].States[item.state]; ok {
-			for i, count := range item.counts.Counts {
-				state.Counts[i].Recovered = count.Recovered
-			}
-		} else {
-			if _, ok := caseCountsMap[item.country]; !ok {
-				countryName, _ := utils.GetCountryFromAbbreviation(item.country)
-				caseCountsMap[item.country] = CountryWithStates{countryName, map[string]CaseCounts{}}
+	return countries[iso]
+}
+
+// ingestCaseCounts : stream all the John Hopkins CSV files into per state time series
+func ingestCaseCounts() (map[string]*countrySeries, []string, error) {
+	ing := newIngestion()
+	files := []struct {
+		url    string
+		rowFn  utils.CSVRowFn
+		doneFn func() error
+	}{
+		{confirmedURL, ing.addConfirmedRow, nil},
+		{deathsURL, ing.addDeathsRow, ing.finishGlobalRows},
+		{recoveredURL, ing.addRecoveredRow, nil},
+		{usConfirmedURL, ing.addUSConfirmedRow, nil},
+		{usDeathsURL, ing.addUSDeathsRow, ing.finishUSRows},
+	}
+	for _, file := range files {
+		if err := utils.StreamCSVFromURL(client, file.url, file.rowFn); err != nil {
+			return nil, nil, err
+		}
+		if file.doneFn != nil {
+			if err := file.doneFn(); err != nil {
+				return nil, nil, fmt.Errorf("Error was encountered in the data from %s: %s", file.url, err.Error())
 			}
-			caseCountsMap[item.country].States[item.state] = item.counts
 		}
 	}
-	return caseCountsMap
+	return ing.countries, ing.headerRow, nil
 }
 
-func mergeCaseCountsWithUS(caseCountsMap map[string]CountryWithStates, usCaseCounts map[string]CaseCounts) map[string]CountryWithStates {
-	usInfo := caseCountsMap["US"]
-	for i := range usInfo.States[""].Counts {
-		usInfo.States[""].Counts[i].Confirmed = 0
-		usInfo.States[""].Counts[i].Deaths = 0
+func (ing *ingestion) checkHeaderRow(row []string, startIndex int) error {
+	if ing.headerRow == nil {
+		return fmt.Errorf("confirmed cases must be read first")
 	}
-	for key, value := range usCaseCounts {
-		usInfo.States[key] = value
-	}
-	return caseCountsMap
-}
-
-func extractUSCaseCounts(confirmedData [][]string, deathsData [][]string) map[string]CaseCounts {
-	headerRow := confirmedData[0]
-	usInfo := make(map[string]CaseCounts)
-	for rowIndex := 1; rowIndex < len(confirmedData); rowIndex++ {
-		confirmedRow := confirmedData[rowIndex]
-		state := confirmedRow[6]
-		if stateInfo, ok := usInfo[state]; ok {
-			if counts, ok := getCaseCountsArray(headerRow, confirmedRow, deathsData[rowIndex], nil, 11, 1); ok {
-				for i, count := range counts {
-					stateInfo.Counts[i].Confirmed += count.Confirmed
-					stateInfo.Counts[i].Deaths += count.Deaths
-				}
-			}
-		} else {
-			lat, err := strconv.ParseFloat(confirmedRow[8], 32)
-			if err != nil {
-				log.Println(err.Error())
-				return nil
-			}
-			long, err := strconv.ParseFloat(confirmedRow[9], 32)
-			if err != nil {
-				log.Println(err.Error())
-				return nil
-			}
-			if counts, ok := getCaseCountsArray(headerRow, confirmedRow, deathsData[rowIndex], nil, 11, 1); ok {
-				usInfo[state] = CaseCounts{LocationAndPopulation{float32(lat), float32(long), utils.StatePopulationLookup["US"][state]}, counts}
-			}
+	if len(row)-startIndex != ing.numDays || row[len(row)-1] != ing.headerRow[len(ing.headerRow)-1] {
+		return fmt.Errorf("dates do not match the dates of the confirmed cases")
+	}
+	return nil
+}
+
+func (ing *ingestion) addConfirmedRow(rowIndex int, row []string) error {
+	if rowIndex == 0 {
+		if len(row) <= globalCountsStartIndex {
+			return fmt.Errorf("header row has no dates")
 		}
+		ing.headerRow = row
+		ing.numDays = len(row) - globalCountsStartIndex
+		return nil
 	}
-	return usInfo
+	state, iso, location, ok := getLocationData(row)
+	series := newTimeSeries(ing.numDays)
+	if !ok || !parseCounts(row, globalCountsStartIndex, series.confirmed) {
+		ing.globalRows = append(ing.globalRows, nil)
+		return nil
+	}
+	ing.globalRows = append(ing.globalRows, &extractedInformation{state, iso, &locationSeries{location, series}})
+	return nil
 }
 
-func getData() ([][]string, [][]string, [][]string, [][]string, [][]string, bool) {
-	confirmedData, confirmedOk := utils.ReadCSVFromURL(client, confirmedURL)
-	deathsData, deathsOk := utils.ReadCSVFromURL(client, deathsURL)
-	recoveredData, recoveredOk := utils.ReadCSVFromURL(client, recoveredURL)
-	usConfirmedData, usConfirmedOk := utils.ReadCSVFromURL(client, usConfirmedURL)
-	usDeathsData, usDeathsOk := utils.ReadCSVFromURL(client, usDeathsURL)
-	return confirmedData, deathsData, recoveredData, usConfirmedData, usDeathsData,
-		confirmedOk && deathsOk && recoveredOk && usConfirmedOk && usDeathsOk
+func (ing *ingestion) addDeathsRow(rowIndex int, row []string) error {
+	if rowIndex == 0 {
+		return ing.checkHeaderRow(row, globalCountsStartIndex)
+	}
+	if rowIndex > len(ing.globalRows) {
+		return fmt.Errorf("there are more rows than in the confirmed cases")
+	}
+	item := ing.globalRows[rowIndex-1]
+	if item == nil {
+		return nil
+	}
+	if iso, _ := utils.GetAbbreviationFromCountry(row[1]); iso != item.country || row[0] != item.state {
+		return fmt.Errorf("%s, %s does not match the confirmed cases row %s, %s", row[0], row[1], item.state, item.country)
+	}
+	if !parseCounts(row, globalCountsStartIndex, item.series.deaths) {
+		ing.globalRows[rowIndex-1] = nil
+	}
+	return nil
 }
 
-func getColumnValue(row []string, colIndex int) (int, bool) {
-	if row != nil {
-		count, err := strconv.Atoi(row[colIndex])
-		if err != nil {
-			log.Println(err.Error())
-			return 0, false
-		}
-		if count < 0 {
-			return 0, false
+// finishGlobalRows : add the rows that have both confirmed cases and deaths to the countries
+func (ing *ingestion) finishGlobalRows() error {
+	if len(ing.globalRows) == 0 {
+		return fmt.Errorf("no confirmed cases were found")
+	}
+	for _, item := range ing.globalRows {
+		if item != nil {
+			getOrCreateCountrySeries(ing.countries, item.country).states[item.state] = item.series
 		}
-		return count, true
 	}
-	return 0, true
+	ing.globalRows = nil
+	return nil
 }
 
-func getCaseCountsArray(headerRow []string, confirmedRow []string, deathsRow []string, recoveredRow []string, startIndex int, deathsColOffset int) ([]CaseCount, bool) {
-	var counts []CaseCount
-	previousRecovered := 0
-	for colIndex := startIndex; colIndex < len(headerRow); colIndex++ {
-		confirmedCount, confirmedOk := getColumnValue(confirmedRow, colIndex)
-		deathsCount, deathsOk := getColumnValue(deathsRow, colIndex+deathsColOffset)
-		recoveredCount, recoveredOk := getColumnValue(recoveredRow, colIndex)
-		if !(confirmedOk && deathsOk && recoveredOk) {
-			return nil, false
+func (ing *ingestion) addRecoveredRow(rowIndex int, row []string) error {
+	if rowIndex == 0 {
+		return ing.checkHeaderRow(row, globalCountsStartIndex)
+	}
+	state, iso, location, ok := getLocationData(row)
+	recovered := make([]int32, ing.numDays)
+	if !ok || !parseCounts(row, globalCountsStartIndex, recovered) {
+		return nil
+	}
+	fillDiscontinuedRecovered(recovered)
+	countryInfo := getOrCreateCountrySeries(ing.countries, iso)
+	if stateInfo, ok := countryInfo.states[state]; ok {
+		copy(stateInfo.recovered, recovered)
+	} else {
+		series := timeSeries{make([]int32, ing.numDays), make([]int32, ing.numDays), recovered}
+		countryInfo.states[state] = &locationSeries{location, series}
+	}
+	return nil
+}
+
+func (ing *ingestion) addUSConfirmedRow(rowIndex int, row []string) error {
+	if rowIndex == 0 {
+		return ing.checkHeaderRow(row, usCountsStartIndex)
+	}
+	lat, latErr := strconv.ParseFloat(row[8], 32)
+	long, longErr := strconv.ParseFloat(row[9], 32)
+	series := newTimeSeries(ing.numDays)
+	if latErr != nil || longErr != nil || !parseCounts(row, usCountsStartIndex, series.confirmed) {
+		log.Printf("Skipping faulty US confirmed cases row %d\n", rowIndex)
+		ing.usCounties = append(ing.usCounties, nil)
+		return nil
+	}
+	state := row[6]
+	location := LocationAndPopulation{float32(lat), float32(long), utils.StatePopulationLookup["US"][state]}
+	ing.usCounties = append(ing.usCounties, &usCounty{state, location, series})
+	return nil
+}
+
+func (ing *ingestion) addUSDeathsRow(rowIndex int, row []string) error {
+	if rowIndex == 0 {
+		return ing.checkHeaderRow(row, usDeathsCountsStartIndex)
+	}
+	if rowIndex > len(ing.usCounties) {
+		return fmt.Errorf("there are more rows than in the US confirmed cases")
+	}
+	county := ing.usCounties[rowIndex-1]
+	if county == nil {
+		return nil
+	}
+	if row[6] != county.state {
+		return fmt.Errorf("%s does not match the US confirmed cases row %s", row[6], county.state)
+	}
+	if !parseCounts(row, usDeathsCountsStartIndex, county.series.deaths) {
+		ing.usCounties[rowIndex-1] = nil
+	}
+	return nil
+}
+
+// finishUSRows : sum up the counties into their states and replace the country level US data with the states
+func (ing *ingestion) finishUSRows() error {
+	usCaseCounts := make(map[string]*locationSeries)
+	for _, county := range ing.usCounties {
+		if county == nil {
+			continue
 		}
-		if recoveredCount == 0 {
-			// workaround for https://github.com/CSSEGISandData/COVID-19/issues/4465,
-			// recovery data is discontinued
-			recoveredCount = previousRecovered
+		if stateInfo, ok := usCaseCounts[county.state]; ok {
+			stateInfo.add(county.series)
 		} else {
-			previousRecovered = recoveredCount
+			usCaseCounts[county.state] = &locationSeries{county.location, county.series}
+		}
+	}
+	ing.usCounties = nil
+	mergeCaseCountsWithUS(ing.countries, usCaseCounts)
+	return nil
+}
+
+func mergeCaseCountsWithUS(countries map[string]*countrySeries, usCaseCounts map[string]*locationSeries) map[string]*countrySeries {
+	usInfo := getOrCreateCountrySeries(countries, "US")
+	if countryLevel, ok := usInfo.states[""]; ok {
+		for i := range countryLevel.confirmed {
+			countryLevel.confirmed[i] = 0
+			countryLevel.deaths[i] = 0
+		}
+	}
+	for key, value := range usCaseCounts {
+		usInfo.states[key] = value
+	}
+	return countries
+}
+
+func getColumnValue(row []string, colIndex int) (int32, bool) {
+	count, err := strconv.ParseInt(row[colIndex], 10, 32)
+	if err != nil {
+		log.Println(err.Error())
+		return 0, false
+	}
+	if count < 0 {
+		return 0, false
+	}
+	return int32(count), true
+}
+
+// parseCounts : parse the counts in row from startIndex onwards into counts, returning false if any of them is faulty
+func parseCounts(row []string, startIndex int, counts []int32) bool {
+	if len(row)-startIndex != len(counts) {
+		return false
+	}
+	for i := range counts {
+		count, ok := getColumnValue(row, startIndex+i)
+		if !ok {
+			return false
+		}
+		counts[i] = count
+	}
+	return true
+}
+
+// fillDiscontinuedRecovered : workaround for https://github.com/CSSEGISandData/COVID-19/issues/4465,
+// recovery data is discontinued so days without recoveries keep the previous count
+func fillDiscontinuedRecovered(recovered []int32) {
+	for i := 1; i < len(recovered); i++ {
+		if recovered[i] == 0 {
+			recovered[i] = recovered[i-1]
 		}
-		caseCountItem := CaseCount{headerRow[colIndex], statistics{confirmedCount, deathsCount, recoveredCount}}
-		counts = append(counts, caseCountItem)
 	}
-	return counts, true
 }
 
-func getCaseCountsData(headerRow []string, confirmedRow []string, deathsRow []string, recoveredRow []string, rowDetails []string, ch chan extractedInformation, wg *sync.WaitGroup) {
-	defer wg.Done()
-	counts, ok := getCaseCountsArray(headerRow, confirmedRow, deathsRow, recoveredRow, 4, 0)
-	iso, lookupOk := utils.GetAbbreviationFromCountry(rowDetails[1])
-	if !ok || !lookupOk {
-		return
+// getLocationData : get the state, iso code of the country and the location of a row of the global CSV files
+func getLocationData(row []string) (string, string, LocationAndPopulation, bool) {
+	iso, lookupOk := utils.GetAbbreviationFromCountry(row[1])
+	if !lookupOk {
+		return "", "", LocationAndPopulation{}, false
 	}
-	lat, latError := strconv.ParseFloat(rowDetails[2], 32)
+	lat, latError := strconv.ParseFloat(row[2], 32)
 	if latError != nil {
 		log.Println(latError.Error())
-		return
+		return "", "", LocationAndPopulation{}, false
 	}
-	long, longError := strconv.ParseFloat(rowDetails[3], 32)
+	long, longError := strconv.ParseFloat(row[3], 32)
 	if longError != nil {
 		log.Println(longError.Error())
-		return
+		return "", "", LocationAndPopulation{}, false
 	}
-	ch <- extractedInformation{rowDetails[0], iso, CaseCounts{LocationAndPopulation{float32(lat), float32(long), utils.StatePopulationLookup[iso][rowDetails[0]]}, counts}}
+	return row[0], iso, LocationAndPopulation{float32(lat), float32(long), utils.StatePopulationLookup[iso][row[0]]}, true
 }
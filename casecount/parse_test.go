@@ -0,0 +1,129 @@
+package casecount
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type mockFilesClient struct {
+	files map[string]string
+}
+
+func (m *mockFilesClient) Get(url string) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: 200,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(m.files[url]))),
+	}, nil
+}
+
+func getTestFiles() map[string]string {
+	return map[string]string{
+		confirmedURL:   "Province/State,Country/Region,Lat,Long,1/22/20,1/23/20\n,Afghanistan,33.0,65.1,2,3\n,Albania,41.1533,20.1683,4,5\n,US,37.0902,-95.7129,10,11",
+		deathsURL:      "Province/State,Country/Region,Lat,Long,1/22/20,1/23/20\n,Afghanistan,33.0,65.1,1,1\n,Albania,41.1533,20.1683,2,2\n,US,37.0902,-95.7129,3,3",
+		recoveredURL:   "Province/State,Country/Region,Lat,Long,1/22/20,1/23/20\n,Afghanistan,33.0,65.1,1,0",
+		usConfirmedURL: "UID,iso2,iso3,code3,FIPS,Admin2,Province_State,Country_Region,Lat,Long_,Combined_Key,1/22/20,1/23/20\n16,AS,ASM,16,60.0,,American Samoa,US,-14.27,-170.132,\"American Samoa, US\",2,2\n16,AS,ASM,16,60.0,substate,American Samoa,US,-14.27,-170.132,\"American Samoa, US\",2,3",
+		usDeathsURL:    "UID,iso2,iso3,code3,FIPS,Admin2,Province_State,Country_Region,Lat,Long_,Combined_Key,Population,1/22/20,1/23/20\n16,AS,ASM,16,60.0,,American Samoa,US,-14.27,-170.132,\"American Samoa, US\",55641,1,1\n16,AS,ASM,16,60.0,substate,American Samoa,US,-14.27,-170.132,\"American Samoa, US\",55641,0,1",
+	}
+}
+
+func TestIngestCaseCounts(t *testing.T) {
+	client = &mockFilesClient{getTestFiles()}
+	countries, headerRow, err := ingestCaseCounts()
+	if err != nil {
+		t.Fatalf("Ingestion should not have failed, got: %s.", err.Error())
+	}
+	if len(headerRow) != 6 {
+		t.Errorf("Header row is incorrect, got: %v.", headerRow)
+	}
+	expectedData := map[string]CountryWithStates{
+		"AF": CountryWithStates{
+			Name: "Afghanistan",
+			States: map[string]CaseCounts{
+				"": CaseCounts{
+					LocationAndPopulation{33.0, 65.1, 5000},
+					[]CaseCount{CaseCount{"1/22/20", statistics{2, 1, 1}, nil}, CaseCount{"1/23/20", statistics{3, 1, 1}, nil}},
+				},
+			},
+		},
+		"US": CountryWithStates{
+			Name: "US",
+			States: map[string]CaseCounts{
+				"": CaseCounts{
+					LocationAndPopulation{37.0902, -95.7129, 300000},
+					[]CaseCount{CaseCount{"1/22/20", statistics{0, 0, 0}, nil}, CaseCount{"1/23/20", statistics{0, 0, 0}, nil}},
+				},
+				"American Samoa": CaseCounts{
+					LocationAndPopulation{-14.27, -170.132, 40000},
+					[]CaseCount{CaseCount{"1/22/20", statistics{4, 1, 0}, nil}, CaseCount{"1/23/20", statistics{5, 2, 0}, nil}},
+				},
+			},
+		},
+	}
+	verifyResultsCaseCountsMap(toCaseCountsMap(countries, headerRow[4:]), expectedData, t)
+}
+
+func TestIngestCaseCounts_FaultyValuesSkipRow(t *testing.T) {
+	files := getTestFiles()
+	files[deathsURL] = strings.Replace(files[deathsURL], "Albania,41.1533,20.1683,2,2", "Albania,41.1533,20.1683,2,a", 1)
+	client = &mockFilesClient{files}
+	countries, _, err := ingestCaseCounts()
+	if err != nil {
+		t.Fatalf("Ingestion should not have failed, got: %s.", err.Error())
+	}
+	if _, ok := countries["AL"]; ok {
+		t.Error("Row with faulty values should have been skipped.")
+	}
+	if _, ok := countries["AF"]; !ok {
+		t.Error("Rows without faulty values should have been kept.")
+	}
+}
+
+func TestIngestCaseCounts_MalformedFilesAbort(t *testing.T) {
+	tables := []struct {
+		url         string
+		replaceFrom string
+		replaceTo   string
+		errorString string
+	}{
+		{deathsURL, ",Albania,41.1533,20.1683,2,2\n,US", ",US,37.0902,-95.7129,2,2\n,Albania", "does not match"},
+		{deathsURL, "1/22/20,1/23/20", "1/22/20,1/24/20", "dates do not match"},
+		{recoveredURL, "65.1,1,0", "65.1,1", "wrong number of fields"},
+		{usDeathsURL, "55641,0,1", "55641,0,1\n16,AS,ASM,16,60.0,extra,American Samoa,US,-14.27,-170.132,\"American Samoa, US\",55641,0,1", "more rows"},
+		{confirmedURL, "Province/State,Country/Region,Lat,Long,1/22/20,1/23/20\n,Afghanistan,33.0,65.1,2,3\n,Albania,41.1533,20.1683,4,5\n,US,37.0902,-95.7129,10,11", "Province/State,Country/Region,Lat,Long,1/22/20,1/23/20", "more rows"},
+	}
+	for _, table := range tables {
+		files := getTestFiles()
+		files[table.url] = strings.Replace(files[table.url], table.replaceFrom, table.replaceTo, 1)
+		client = &mockFilesClient{files}
+		_, _, err := ingestCaseCounts()
+		if err == nil || !strings.Contains(err.Error(), table.errorString) {
+			t.Errorf("Ingestion should have been aborted with an error containing %q, got: %v.", table.errorString, err)
+		}
+	}
+}
+
+func TestUpdateCaseCounts_KeepsOldDataWhenFilesAreMalformed(t *testing.T) {
+	dataset := publishTestDataset()
+	files := getTestFiles()
+	files[deathsURL] = strings.Replace(files[deathsURL], "1/22/20,1/23/20", "1/22/20,1/24/20", 1)
+	client = &mockFilesClient{files}
+	UpdateCaseCounts()
+	if getDataset() != dataset {
+		t.Error("Dataset should not have been replaced by faulty data.")
+	}
+}
+
+func TestFillDiscontinuedRecovered(t *testing.T) {
+	recovered := []int32{0, 2, 0, 0, 5, 0}
+	fillDiscontinuedRecovered(recovered)
+	expected := []int32{0, 2, 2, 2, 5, 5}
+	for i := range recovered {
+		if recovered[i] != expected[i] {
+			t.Errorf("Recovered counts are incorrect, got: %v, want: %v.", recovered, expected)
+			break
+		}
+	}
+}
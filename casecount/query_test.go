@@ -2,8 +2,18 @@ package casecount
 
 import (
 	"testing"
+
+	"yet-another-covid-map-api/dateformat"
 )
 
+var testHeaderRow = []string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"}
+
+func publishTestDataset() *Dataset {
+	dataset := newDataset(toCountrySeriesMap(getTestCaseCounts()), testHeaderRow)
+	publishDataset(dataset)
+	return dataset
+}
+
 func getTestCaseCounts() map[string]CountryWithStates {
 	result := map[string]CountryWithStates{
 		"CN": CountryWithStates{
@@ -12,34 +22,34 @@ func getTestCaseCounts() map[string]CountryWithStates {
 				"Beijing": CaseCounts{
 					LocationAndPopulation{40.1824, 116.4142, 50000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{50, 10, 0}},
-						CaseCount{"1/23/20", statistics{200, 87, 10}},
-						CaseCount{"1/24/20", statistics{800, 125, 30}},
-						CaseCount{"1/25/20", statistics{1020, 142, 50}},
-						CaseCount{"1/26/20", statistics{1110, 145, 60}},
-						CaseCount{"1/27/20", statistics{1235, 152, 90}},
+						CaseCount{"1/22/20", statistics{50, 10, 0}, nil},
+						CaseCount{"1/23/20", statistics{200, 87, 10}, nil},
+						CaseCount{"1/24/20", statistics{800, 125, 30}, nil},
+						CaseCount{"1/25/20", statistics{1020, 142, 50}, nil},
+						CaseCount{"1/26/20", statistics{1110, 145, 60}, nil},
+						CaseCount{"1/27/20", statistics{1235, 152, 90}, nil},
 					},
 				},
 				"Hubei": CaseCounts{
 					LocationAndPopulation{30.9756, 112.2707, 30000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{100, 20, 0}},
-						CaseCount{"1/23/20", statistics{1000, 100, 50}},
-						CaseCount{"1/24/20", statistics{1800, 105, 140}},
-						CaseCount{"1/25/20", statistics{2020, 150, 240}},
-						CaseCount{"1/26/20", statistics{2110, 175, 350}},
-						CaseCount{"1/27/20", statistics{2111, 230, 460}},
+						CaseCount{"1/22/20", statistics{100, 20, 0}, nil},
+						CaseCount{"1/23/20", statistics{1000, 100, 50}, nil},
+						CaseCount{"1/24/20", statistics{1800, 105, 140}, nil},
+						CaseCount{"1/25/20", statistics{2020, 150, 240}, nil},
+						CaseCount{"1/26/20", statistics{2110, 175, 350}, nil},
+						CaseCount{"1/27/20", statistics{2111, 230, 460}, nil},
 					},
 				},
 				"Shanghai": CaseCounts{
 					LocationAndPopulation{31.202, 121.4491, 40000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{10, 5, 0}},
-						CaseCount{"1/23/20", statistics{45, 8, 2}},
-						CaseCount{"1/24/20", statistics{89, 20, 4}},
-						CaseCount{"1/25/20", statistics{126, 25, 5}},
-						CaseCount{"1/26/20", statistics{400, 42, 7}},
-						CaseCount{"1/27/20", statistics{532, 55, 10}},
+						CaseCount{"1/22/20", statistics{10, 5, 0}, nil},
+						CaseCount{"1/23/20", statistics{45, 8, 2}, nil},
+						CaseCount{"1/24/20", statistics{89, 20, 4}, nil},
+						CaseCount{"1/25/20", statistics{126, 25, 5}, nil},
+						CaseCount{"1/26/20", statistics{400, 42, 7}, nil},
+						CaseCount{"1/27/20", statistics{532, 55, 10}, nil},
 					},
 				},
 			},
@@ -50,12 +60,12 @@ func getTestCaseCounts() map[string]CountryWithStates {
 				"": CaseCounts{
 					LocationAndPopulation{1.2833, 103.8333, 6000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{1, 0, 0}},
-						CaseCount{"1/23/20", statistics{3, 2, 0}},
-						CaseCount{"1/24/20", statistics{6, 4, 1}},
-						CaseCount{"1/25/20", statistics{10, 5, 2}},
-						CaseCount{"1/26/20", statistics{15, 8, 4}},
-						CaseCount{"1/27/20", statistics{23, 10, 6}},
+						CaseCount{"1/22/20", statistics{1, 0, 0}, nil},
+						CaseCount{"1/23/20", statistics{3, 2, 0}, nil},
+						CaseCount{"1/24/20", statistics{6, 4, 1}, nil},
+						CaseCount{"1/25/20", statistics{10, 5, 2}, nil},
+						CaseCount{"1/26/20", statistics{15, 8, 4}, nil},
+						CaseCount{"1/27/20", statistics{23, 10, 6}, nil},
 					},
 				},
 			},
@@ -66,12 +76,12 @@ func getTestCaseCounts() map[string]CountryWithStates {
 				"London": CaseCounts{
 					LocationAndPopulation{55.3781, -3.4360000000000004, 7000},
 					[]CaseCount{
-						CaseCount{"1/22/20", statistics{1, 0, 0}},
-						CaseCount{"1/23/20", statistics{6, 1, 0}},
-						CaseCount{"1/24/20", statistics{8, 3, 0}},
-						CaseCount{"1/25/20", statistics{9, 6, 2}},
-						CaseCount{"1/26/20", statistics{20, 6, 5}},
-						CaseCount{"1/27/20", statistics{28, 9, 10}},
+						CaseCount{"1/22/20", statistics{1, 0, 0}, nil},
+						CaseCount{"1/23/20", statistics{6, 1, 0}, nil},
+						CaseCount{"1/24/20", statistics{8, 3, 0}, nil},
+						CaseCount{"1/25/20", statistics{9, 6, 2}, nil},
+						CaseCount{"1/26/20", statistics{20, 6, 5}, nil},
+						CaseCount{"1/27/20", statistics{28, 9, 10}, nil},
 					},
 				},
 			},
@@ -88,28 +98,28 @@ func getTestCaseCountsWithoutFirstAndLastDay() map[string]CountryWithStates {
 				"Beijing": CaseCounts{
 					LocationAndPopulation{40.1824, 116.4142, 50000},
 					[]CaseCount{
-						CaseCount{"1/23/20", statistics{200, 87, 10}},
-						CaseCount{"1/24/20", statistics{800, 125, 30}},
-						CaseCount{"1/25/20", statistics{1020, 142, 50}},
-						CaseCount{"1/26/20", statistics{1110, 145, 60}},
+						CaseCount{"1/23/20", statistics{200, 87, 10}, nil},
+						CaseCount{"1/24/20", statistics{800, 125, 30}, nil},
+						CaseCount{"1/25/20", statistics{1020, 142, 50}, nil},
+						CaseCount{"1/26/20", statistics{1110, 145, 60}, nil},
 					},
 				},
 				"Hubei": CaseCounts{
 					LocationAndPopulation{30.9756, 112.2707, 30000},
 					[]CaseCount{
-						CaseCount{"1/23/20", statistics{1000, 100, 50}},
-						CaseCount{"1/24/20", statistics{1800, 105, 140}},
-						CaseCount{"1/25/20", statistics{2020, 150, 240}},
-						CaseCount{"1/26/20", statistics{2110, 175, 350}},
+						CaseCount{"1/23/20", statistics{1000, 100, 50}, nil},
+						CaseCount{"1/24/20", statistics{1800, 105, 140}, nil},
+						CaseCount{"1/25/20", statistics{2020, 150, 240}, nil},
+						CaseCount{"1/26/20", statistics{2110, 175, 350}, nil},
 					},
 				},
 				"Shanghai": CaseCounts{
 					LocationAndPopulation{31.202, 121.4491, 40000},
 					[]CaseCount{
-						CaseCount{"1/23/20", statistics{45, 8, 2}},
-						CaseCount{"1/24/20", statistics{89, 20, 4}},
-						CaseCount{"1/25/20", statistics{126, 25, 5}},
-						CaseCount{"1/26/20", statistics{400, 42, 7}},
+						CaseCount{"1/23/20", statistics{45, 8, 2}, nil},
+						CaseCount{"1/24/20", statistics{89, 20, 4}, nil},
+						CaseCount{"1/25/20", statistics{126, 25, 5}, nil},
+						CaseCount{"1/26/20", statistics{400, 42, 7}, nil},
 					},
 				},
 			},
@@ -120,10 +130,10 @@ func getTestCaseCountsWithoutFirstAndLastDay() map[string]CountryWithStates {
 				"": CaseCounts{
 					LocationAndPopulation{1.2833, 103.8333, 6000},
 					[]CaseCount{
-						CaseCount{"1/23/20", statistics{3, 2, 0}},
-						CaseCount{"1/24/20", statistics{6, 4, 1}},
-						CaseCount{"1/25/20", statistics{10, 5, 2}},
-						CaseCount{"1/26/20", statistics{15, 8, 4}},
+						CaseCount{"1/23/20", statistics{3, 2, 0}, nil},
+						CaseCount{"1/24/20", statistics{6, 4, 1}, nil},
+						CaseCount{"1/25/20", statistics{10, 5, 2}, nil},
+						CaseCount{"1/26/20", statistics{15, 8, 4}, nil},
 					},
 				},
 			},
@@ -134,10 +144,10 @@ func getTestCaseCountsWithoutFirstAndLastDay() map[string]CountryWithStates {
 				"London": CaseCounts{
 					LocationAndPopulation{55.3781, -3.4360000000000004, 7000},
 					[]CaseCount{
-						CaseCount{"1/23/20", statistics{6, 1, 0}},
-						CaseCount{"1/24/20", statistics{8, 3, 0}},
-						CaseCount{"1/25/20", statistics{9, 6, 2}},
-						CaseCount{"1/26/20", statistics{20, 6, 5}},
+						CaseCount{"1/23/20", statistics{6, 1, 0}, nil},
+						CaseCount{"1/24/20", statistics{8, 3, 0}, nil},
+						CaseCount{"1/25/20", statistics{9, 6, 2}, nil},
+						CaseCount{"1/26/20", statistics{20, 6, 5}, nil},
 					},
 				},
 			},
@@ -173,7 +183,11 @@ func TestExtractCaseCounts(t *testing.T) {
 		{"", "US", "37.0902", "-95.7129", "0", "0", "0", "50", "100", "150"},
 	}
 	headerRow := confirmedData[0]
-	result := extractCaseCounts(headerRow, confirmedData, deathsData, recoveredData)
+	countries, err := ingestGlobalTables(confirmedData, deathsData, recoveredData)
+	if err != nil {
+		t.Errorf("Ingestion should not have failed, got: %s.", err.Error())
+	}
+	result := toCaseCountsMap(countries, headerRow[4:])
 	expectedData := getTestCaseCounts()
 	expectedData["US"] = CountryWithStates{
 		Name: "US",
@@ -181,12 +195,12 @@ func TestExtractCaseCounts(t *testing.T) {
 			"": CaseCounts{
 				LocationAndPopulation{37.0902, -95.7129, 300000},
 				[]CaseCount{
-					CaseCount{"1/22/20", statistics{0, 0, 0}},
-					CaseCount{"1/23/20", statistics{0, 0, 0}},
-					CaseCount{"1/24/20", statistics{0, 0, 0}},
-					CaseCount{"1/25/20", statistics{0, 0, 50}},
-					CaseCount{"1/26/20", statistics{0, 0, 100}},
-					CaseCount{"1/27/20", statistics{0, 0, 150}},
+					CaseCount{"1/22/20", statistics{0, 0, 0}, nil},
+					CaseCount{"1/23/20", statistics{0, 0, 0}, nil},
+					CaseCount{"1/24/20", statistics{0, 0, 0}, nil},
+					CaseCount{"1/25/20", statistics{0, 0, 50}, nil},
+					CaseCount{"1/26/20", statistics{0, 0, 100}, nil},
+					CaseCount{"1/27/20", statistics{0, 0, 150}, nil},
 				},
 			},
 		},
@@ -195,9 +209,8 @@ func TestExtractCaseCounts(t *testing.T) {
 }
 
 func TestAggregateDataBetweenDates_AllDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result := stateAggregatedMap
+	dataset := publishTestDataset()
+	result := dataset.stateAggregatedMap
 	expectedData := map[string]CountryWithStatesAggregated{
 		"CN": CountryWithStatesAggregated{
 			Name: "China",
@@ -239,9 +252,8 @@ func TestAggregateDataBetweenDates_AllDates(t *testing.T) {
 }
 
 func TestAggregateDataBetweenDates_QueryDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("1/24/20", "1/26/20", "")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/24/20", "1/26/20", "", nil)
 	expectedData := map[string]CountryWithStatesAggregated{
 		"CN": CountryWithStatesAggregated{
 			Name: "China",
@@ -283,27 +295,24 @@ func TestAggregateDataBetweenDates_QueryDates(t *testing.T) {
 }
 
 func TestAggregateDataBetweenDates_QueryDatesBeforeValidRange(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("1/20/20", "1/21/20", "")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/20/20", "1/21/20", "", nil)
 	if len(result) != 0 {
 		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), 0)
 	}
 }
 
 func TestAggregateDataBetweenDates_QueryDatesAfterValidRange(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("1/28/20", "1/29/20", "")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/28/20", "1/29/20", "", nil)
 	if len(result) != 0 {
 		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), 0)
 	}
 }
 
 func TestAggregateDataBetweenDates_QueryDatesBeforeAndAfter_ShouldReturnAll(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("1/21/20", "1/28/20", "")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/21/20", "1/28/20", "", nil)
 	expectedData := map[string]CountryWithStatesAggregated{
 		"CN": CountryWithStatesAggregated{
 			Name: "China",
@@ -345,18 +354,16 @@ func TestAggregateDataBetweenDates_QueryDatesBeforeAndAfter_ShouldReturnAll(t *t
 }
 
 func TestAggregateDataBetweenDates_QueryFromDateAfterToDate(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	_, err := aggregateDataBetweenDates("1/24/20", "1/23/20", "CN")
+	dataset := publishTestDataset()
+	_, err := dataset.aggregateDataBetweenDates("1/24/20", "1/23/20", "CN", nil)
 	if err == nil {
 		t.Error("Error message should be returned.")
 	}
 }
 
 func TestAggregateDataBetweenDates_QueryCountry(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("", "", "SG")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("", "", "SG", nil)
 	expectedData := map[string]CountryWithStatesAggregated{
 		"SG": CountryWithStatesAggregated{
 			Name: "Singapore",
@@ -372,9 +379,8 @@ func TestAggregateDataBetweenDates_QueryCountry(t *testing.T) {
 }
 
 func TestAggregateDataBetweenDates_QueryDates_FromIsOutOfRange(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("1/21/20", "1/26/20", "")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/21/20", "1/26/20", "", nil)
 	expectedData := map[string]CountryWithStatesAggregated{
 		"CN": CountryWithStatesAggregated{
 			Name: "China",
@@ -416,9 +422,8 @@ func TestAggregateDataBetweenDates_QueryDates_FromIsOutOfRange(t *testing.T) {
 }
 
 func TestAggregateDataBetweenDates_QueryDates_ToIsOutOfRange(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("1/24/20", "1/28/20", "")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/24/20", "1/28/20", "", nil)
 	expectedData := map[string]CountryWithStatesAggregated{
 		"CN": CountryWithStatesAggregated{
 			Name: "China",
@@ -460,9 +465,8 @@ func TestAggregateDataBetweenDates_QueryDates_ToIsOutOfRange(t *testing.T) {
 }
 
 func TestAggregateDataBetweenDates_QueryDates_FromAndToBothOutOfRange(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := aggregateDataBetweenDates("1/21/20", "1/28/20", "")
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/21/20", "1/28/20", "", nil)
 	expectedData := map[string]CountryWithStatesAggregated{
 		"CN": CountryWithStatesAggregated{
 			Name: "China",
@@ -503,10 +507,9 @@ func TestAggregateDataBetweenDates_QueryDates_FromAndToBothOutOfRange(t *testing
 	verifyResultsCaseCountsAgg(result, expectedData, t)
 }
 
-func TestAggregateCountryDataFromStatesAggregate_AllDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result := countryAggregatedMap
+func TestAggregateCountryDataBetweenDates_AllDates(t *testing.T) {
+	dataset := publishTestDataset()
+	result := dataset.countryAggregatedMap
 	expectedData := map[string]CountryAggregated{
 		"CN": CountryAggregated{
 			"China",
@@ -533,45 +536,9 @@ func TestAggregateCountryDataFromStatesAggregate_AllDates(t *testing.T) {
 	verifyResultsCountryCaseCountsAgg(result, expectedData, t)
 }
 
-func TestAggregateCountryDataFromStatesAggregate_QueryDates(t *testing.T) {
-	input := map[string]CountryWithStatesAggregated{
-		"CN": CountryWithStatesAggregated{
-			Name: "China",
-			States: map[string]CaseCountsAggregated{
-				"Beijing": CaseCountsAggregated{
-					LocationAndPopulation{40.1824, 116.4142, 50000},
-					statistics{910, 58, 50},
-				},
-				"Hubei": CaseCountsAggregated{
-					LocationAndPopulation{30.9756, 112.2707, 30000},
-					statistics{1110, 75, 300},
-				},
-				"Shanghai": CaseCountsAggregated{
-					LocationAndPopulation{31.202, 121.4491, 40000},
-					statistics{355, 34, 5},
-				},
-			},
-		},
-		"SG": CountryWithStatesAggregated{
-			Name: "Singapore",
-			States: map[string]CaseCountsAggregated{
-				"": CaseCountsAggregated{
-					LocationAndPopulation{1.2833, 103.8333, 6000},
-					statistics{12, 6, 4},
-				},
-			},
-		},
-		"GB": CountryWithStatesAggregated{
-			Name: "United Kingdom",
-			States: map[string]CaseCountsAggregated{
-				"": CaseCountsAggregated{
-					LocationAndPopulation{55.3781, -3.4360000000000004, 7000},
-					statistics{14, 5, 5},
-				},
-			},
-		},
-	}
-	result := aggregateCountryDataFromStatesAggregate(input)
+func TestAggregateCountryDataBetweenDates_QueryDates(t *testing.T) {
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateCountryDataBetweenDates("1/24/20", "1/26/20", "")
 	expectedData := map[string]CountryAggregated{
 		"CN": CountryAggregated{
 			"China",
@@ -599,68 +566,90 @@ func TestAggregateCountryDataFromStatesAggregate_QueryDates(t *testing.T) {
 }
 
 func TestAggregateDataPerDay_AllDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetCaseCountsWithDayData("", "", "")
-	expectedData := caseCountsMap
+	publishTestDataset()
+	result, _ := GetCaseCountsWithDayData("", "", "", nil, "", "")
+	expectedData := getTestCaseCounts()
 	verifyResultsCaseCountsMap(result, expectedData, t)
 }
 
 func TestAggregateDataPerDay_QueryDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetCaseCountsWithDayData("1/23/20", "1/26/20", "")
+	publishTestDataset()
+	result, _ := GetCaseCountsWithDayData("1/23/20", "1/26/20", "", nil, "", "")
 	expectedData := getTestCaseCountsWithoutFirstAndLastDay()
 	verifyResultsCaseCountsMap(result, expectedData, t)
 }
 
 func TestAggregateDataPerDay_BeforeAndAfterShouldReturnAll(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetCaseCountsWithDayData("1/21/20", "1/28/20", "")
-	expectedData := caseCountsMap
+	publishTestDataset()
+	result, _ := GetCaseCountsWithDayData("1/21/20", "1/28/20", "", nil, "", "")
+	expectedData := getTestCaseCounts()
 	verifyResultsCaseCountsMap(result, expectedData, t)
 }
 
 func TestAggregateDataPerDay_CountryQuery(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetCaseCountsWithDayData("", "", "CN")
+	publishTestDataset()
+	result, _ := GetCaseCountsWithDayData("", "", "CN", nil, "", "")
 	expectedData := getTestCaseCounts()["CN"]
 	verifyResultsCaseCountsMap(result, map[string]CountryWithStates{"CN": expectedData}, t)
 }
 
 func TestAggregateDataPerDay_QueryFromDateAfterToDate(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	_, err := GetCaseCountsWithDayData("1/24/20", "1/23/20", "CN")
+	publishTestDataset()
+	_, err := GetCaseCountsWithDayData("1/24/20", "1/23/20", "CN", nil, "", "")
 	if err == nil {
 		t.Error("Error message should be returned.")
 	}
 }
 
 func TestCountryAggregateDataPerDay_AllDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetCountryCaseCountsWithDayData("", "", "")
-	expectedData := countryCaseCountsMap
+	publishTestDataset()
+	result, _ := GetCountryCaseCountsWithDayData("", "", "", "", "")
+	expectedData := map[string]Country{
+		"CN": Country{
+			"China",
+			CaseCounts{
+				LocationAndPopulation{(40.1824 + 30.9756 + 31.202) / 3.0, (116.4142 + 112.2707 + 121.4491) / 3.0, 120000},
+				[]CaseCount{
+					CaseCount{"1/22/20", statistics{160, 35, 0}, nil},
+					CaseCount{"1/23/20", statistics{1245, 195, 62}, nil},
+					CaseCount{"1/24/20", statistics{2689, 250, 174}, nil},
+					CaseCount{"1/25/20", statistics{3166, 317, 295}, nil},
+					CaseCount{"1/26/20", statistics{3620, 362, 417}, nil},
+					CaseCount{"1/27/20", statistics{3878, 437, 560}, nil},
+				},
+			},
+		},
+		"SG": Country{
+			"Singapore",
+			CaseCounts{
+				LocationAndPopulation{1.2833, 103.8333, 6000},
+				getTestCaseCounts()["SG"].States[""].Counts,
+			},
+		},
+		"GB": Country{
+			"United Kingdom",
+			CaseCounts{
+				LocationAndPopulation{55.3781, -3.4360000000000004, 7000},
+				getTestCaseCounts()["GB"].States["London"].Counts,
+			},
+		},
+	}
 	verifyResultsCountryCaseCountsMap(result, expectedData, t)
 }
 
 func TestCountryAggregateDataPerDay_QueryDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetCountryCaseCountsWithDayData("1/23/20", "1/26/20", "")
+	publishTestDataset()
+	result, _ := GetCountryCaseCountsWithDayData("1/23/20", "1/26/20", "", "", "")
 	expectedData := map[string]Country{
 		"CN": Country{
 			"China",
 			CaseCounts{
 				LocationAndPopulation{(40.1824 + 30.9756 + 31.202) / 3.0, (116.4142 + 112.2707 + 121.4491) / 3.0, 120000},
 				[]CaseCount{
-					CaseCount{"1/23/20", statistics{1245, 195, 62}},
-					CaseCount{"1/24/20", statistics{2689, 250, 174}},
-					CaseCount{"1/25/20", statistics{3166, 317, 295}},
-					CaseCount{"1/26/20", statistics{3620, 362, 417}},
+					CaseCount{"1/23/20", statistics{1245, 195, 62}, nil},
+					CaseCount{"1/24/20", statistics{2689, 250, 174}, nil},
+					CaseCount{"1/25/20", statistics{3166, 317, 295}, nil},
+					CaseCount{"1/26/20", statistics{3620, 362, 417}, nil},
 				},
 			},
 		},
@@ -669,10 +658,10 @@ func TestCountryAggregateDataPerDay_QueryDates(t *testing.T) {
 			CaseCounts{
 				LocationAndPopulation{1.2833, 103.8333, 6000},
 				[]CaseCount{
-					CaseCount{"1/23/20", statistics{3, 2, 0}},
-					CaseCount{"1/24/20", statistics{6, 4, 1}},
-					CaseCount{"1/25/20", statistics{10, 5, 2}},
-					CaseCount{"1/26/20", statistics{15, 8, 4}},
+					CaseCount{"1/23/20", statistics{3, 2, 0}, nil},
+					CaseCount{"1/24/20", statistics{6, 4, 1}, nil},
+					CaseCount{"1/25/20", statistics{10, 5, 2}, nil},
+					CaseCount{"1/26/20", statistics{15, 8, 4}, nil},
 				},
 			},
 		},
@@ -681,10 +670,10 @@ func TestCountryAggregateDataPerDay_QueryDates(t *testing.T) {
 			CaseCounts{
 				LocationAndPopulation{55.3781, -3.4360000000000004, 7000},
 				[]CaseCount{
-					CaseCount{"1/23/20", statistics{6, 1, 0}},
-					CaseCount{"1/24/20", statistics{8, 3, 0}},
-					CaseCount{"1/25/20", statistics{9, 6, 2}},
-					CaseCount{"1/26/20", statistics{20, 6, 5}},
+					CaseCount{"1/23/20", statistics{6, 1, 0}, nil},
+					CaseCount{"1/24/20", statistics{8, 3, 0}, nil},
+					CaseCount{"1/25/20", statistics{9, 6, 2}, nil},
+					CaseCount{"1/26/20", statistics{20, 6, 5}, nil},
 				},
 			},
 		},
@@ -693,43 +682,132 @@ func TestCountryAggregateDataPerDay_QueryDates(t *testing.T) {
 }
 
 func TestWorldTotal_AllDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetWorldCaseCounts("", "")
+	publishTestDataset()
+	result, _ := GetWorldCaseCounts("", "", "", "")
 	if len(result) != 6 {
 		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), 3)
 	}
 	expectedData := []CaseCount{
-		CaseCount{"1/22/20", statistics{162, 35, 0}},
-		CaseCount{"1/23/20", statistics{1254, 198, 62}},
-		CaseCount{"1/24/20", statistics{2703, 257, 175}},
-		CaseCount{"1/25/20", statistics{3185, 328, 299}},
-		CaseCount{"1/26/20", statistics{3655, 376, 426}},
-		CaseCount{"1/27/20", statistics{3929, 456, 576}},
+		CaseCount{"1/22/20", statistics{162, 35, 0}, nil},
+		CaseCount{"1/23/20", statistics{1254, 198, 62}, nil},
+		CaseCount{"1/24/20", statistics{2703, 257, 175}, nil},
+		CaseCount{"1/25/20", statistics{3185, 328, 299}, nil},
+		CaseCount{"1/26/20", statistics{3655, 376, 426}, nil},
+		CaseCount{"1/27/20", statistics{3929, 456, 576}, nil},
 	}
 	verifyResultsCaseCountArr(result, expectedData, t)
 }
 
 func TestWorldTotal_QueryDates(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	result, _ := GetWorldCaseCounts("1/23/20", "1/26/20")
+	publishTestDataset()
+	result, _ := GetWorldCaseCounts("1/23/20", "1/26/20", "", "")
 	if len(result) != 4 {
 		t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), 3)
 	}
 	expectedData := []CaseCount{
-		CaseCount{"1/23/20", statistics{1254, 198, 62}},
-		CaseCount{"1/24/20", statistics{2703, 257, 175}},
-		CaseCount{"1/25/20", statistics{3185, 328, 299}},
-		CaseCount{"1/26/20", statistics{3655, 376, 426}},
+		CaseCount{"1/23/20", statistics{1254, 198, 62}, nil},
+		CaseCount{"1/24/20", statistics{2703, 257, 175}, nil},
+		CaseCount{"1/25/20", statistics{3185, 328, 299}, nil},
+		CaseCount{"1/26/20", statistics{3655, 376, 426}, nil},
 	}
 	verifyResultsCaseCountArr(result, expectedData, t)
 }
 
+func TestAggregateDataBetweenDates_State(t *testing.T) {
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("", "", "CN", []string{"Hubei"})
+	if len(result) != 1 || len(result["CN"].States) != 1 {
+		t.Fatalf("Result should only contain Hubei, got: %+v.", result)
+	}
+	expected := CaseCountsAggregated{LocationAndPopulation{30.9756, 112.2707, 30000}, statistics{2111, 230, 460}}
+	if hubei := result["CN"].States["Hubei"]; !hubei.equals(expected) {
+		t.Errorf("Result data is incorrect, got: %+v, want %+v.", hubei, expected)
+	}
+	if result, _ := dataset.aggregateDataBetweenDates("", "", "SG", []string{"Hubei"}); len(result) != 0 {
+		t.Errorf("Result should be empty for a state that is not in the country, got: %+v.", result)
+	}
+}
+
+func TestAggregateDataBetweenDates_MultipleStates(t *testing.T) {
+	dataset := publishTestDataset()
+	result, _ := dataset.aggregateDataBetweenDates("1/24/20", "1/26/20", "CN", []string{"Hubei", "Beijing", "Unknown"})
+	expectedData := map[string]CountryWithStatesAggregated{
+		"CN": CountryWithStatesAggregated{
+			Name: "China",
+			States: map[string]CaseCountsAggregated{
+				"Beijing": CaseCountsAggregated{LocationAndPopulation{40.1824, 116.4142, 50000}, statistics{910, 58, 50}},
+				"Hubei":   CaseCountsAggregated{LocationAndPopulation{30.9756, 112.2707, 30000}, statistics{1110, 75, 300}},
+			},
+		},
+	}
+	if len(result) != 1 || len(result["CN"].States) != 2 {
+		t.Errorf("Result should only contain Hubei and Beijing, got: %+v.", result)
+	}
+	for state, stateInfo := range expectedData["CN"].States {
+		if resultInfo := result["CN"].States[state]; !resultInfo.equals(stateInfo) {
+			t.Errorf("Result data is incorrect for %s, got: %+v, want %+v.", state, resultInfo, stateInfo)
+		}
+	}
+}
+
+func TestCaseCountsWithDayData_State(t *testing.T) {
+	publishTestDataset()
+	result, _ := GetCaseCountsWithDayData("1/26/20", "1/27/20", "CN", []string{"Shanghai"}, "", "")
+	expectedData := map[string]CountryWithStates{
+		"CN": CountryWithStates{
+			Name: "China",
+			States: map[string]CaseCounts{
+				"Shanghai": CaseCounts{
+					LocationAndPopulation{31.202, 121.4491, 40000},
+					[]CaseCount{
+						CaseCount{"1/26/20", statistics{400, 42, 7}, nil},
+						CaseCount{"1/27/20", statistics{532, 55, 10}, nil},
+					},
+				},
+			},
+		},
+	}
+	if len(result) != 1 || len(result["CN"].States) != 1 {
+		t.Errorf("Result should only contain Shanghai, got: %+v.", result)
+	}
+	verifyResultsCaseCountsMap(expectedData, result, t)
+}
+
+func TestWorldTotal_DateFormats(t *testing.T) {
+	publishTestDataset()
+	tables := []struct {
+		dateFormat string
+		expected   []string
+	}{
+		{"", []string{"1/26/20", "1/27/20"}},
+		{dateformat.FormatJHU, []string{"1/26/20", "1/27/20"}},
+		{dateformat.FormatISO, []string{"2020-01-26", "2020-01-27"}},
+		{dateformat.FormatUnix, []string{"1579996800", "1580083200"}},
+	}
+	for _, table := range tables {
+		result, _ := GetWorldCaseCounts("1/26/20", "1/27/20", "", table.dateFormat)
+		if len(result) != len(table.expected) {
+			t.Errorf("Length of results is incorrect, got: %d, want %d.", len(result), len(table.expected))
+			continue
+		}
+		for i, caseCount := range result {
+			if caseCount.Date != table.expected[i] {
+				t.Errorf("Date for date format %s is incorrect, got: %s, want: %s.", table.dateFormat, caseCount.Date, table.expected[i])
+			}
+		}
+	}
+}
+
+func TestCaseCountsWithDayData_InvalidDateFormat(t *testing.T) {
+	publishTestDataset()
+	if _, err := GetCaseCountsWithDayData("", "", "", nil, "", dateformat.FormatYearFirst); err == nil {
+		t.Error("Error message should be returned.")
+	}
+}
+
 func TestWorldTotal_QueryFromDateAfterToDate(t *testing.T) {
-	caseCountsMap = getTestCaseCounts()
-	setDateBoundariesAndAllAggregatedData([]string{"Province/State", "Country/Region", "Lat", "Long", "1/22/20", "1/23/20", "1/24/20", "1/25/20", "1/26/20", "1/27/20"})
-	_, err := GetWorldCaseCounts("1/24/20", "1/23/20")
+	publishTestDataset()
+	_, err := GetWorldCaseCounts("1/24/20", "1/23/20", "", "")
 	if err == nil {
 		t.Error("Error message should be returned.")
 	}
@@ -0,0 +1,160 @@
+package casecount
+
+import (
+	"encoding/json"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"yet-another-covid-map-api/dateformat"
+)
+
+const (
+	benchmarkNumCountries = 190
+	benchmarkNumStates    = 5
+	benchmarkNumDays      = 1000
+)
+
+// getBenchmarkCaseCounts : data of roughly the size of the John Hopkins dataset in the per day map representation
+func getBenchmarkCaseCounts() (map[string]CountryWithStates, []string) {
+	firstDate := time.Date(2020, 1, 22, 0, 0, 0, 0, time.UTC)
+	headerRow := []string{"Province/State", "Country/Region", "Lat", "Long"}
+	for day := 0; day < benchmarkNumDays; day++ {
+		headerRow = append(headerRow, firstDate.AddDate(0, 0, day).Format(dateformat.CasesDateFormat))
+	}
+	caseCountsMap := make(map[string]CountryWithStates, benchmarkNumCountries)
+	for country := 0; country < benchmarkNumCountries; country++ {
+		states := make(map[string]CaseCounts, benchmarkNumStates)
+		for state := 0; state < benchmarkNumStates; state++ {
+			counts := make([]CaseCount, benchmarkNumDays)
+			for day := range counts {
+				counts[day] = CaseCount{headerRow[day+4], statistics{day * 10, day, day * 5}, nil}
+			}
+			states["state"+strconv.Itoa(state)] = CaseCounts{LocationAndPopulation{1, 2, 1000}, counts}
+		}
+		caseCountsMap["C"+strconv.Itoa(country)] = CountryWithStates{"Country" + strconv.Itoa(country), states}
+	}
+	return caseCountsMap, headerRow
+}
+
+// mapAggregateDataBetweenDates : aggregation over the per day map representation, as it was done before the columnar storage
+func mapAggregateDataBetweenDates(caseCountsMap map[string]CountryWithStates, fromIndex int, toIndex int) map[string]CountryWithStatesAggregated {
+	aggregatedData := make(map[string]CountryWithStatesAggregated, len(caseCountsMap))
+	for country, countryInfo := range caseCountsMap {
+		newInfo := CountryWithStatesAggregated{countryInfo.Name, make(map[string]CaseCountsAggregated, len(countryInfo.States))}
+		for state, stateInfo := range countryInfo.States {
+			atStartDate := stateInfo.Counts[fromIndex-1].statistics
+			atEndDate := stateInfo.Counts[toIndex].statistics
+			newInfo.States[state] = CaseCountsAggregated{stateInfo.LocationAndPopulation, statistics{
+				atEndDate.Confirmed - atStartDate.Confirmed, atEndDate.Deaths - atStartDate.Deaths, atEndDate.Recovered - atStartDate.Recovered,
+			}}
+		}
+		aggregatedData[country] = newInfo
+	}
+	return aggregatedData
+}
+
+// mapFilterCaseCounts : per day query over the map representation, as it was done before the columnar storage
+func mapFilterCaseCounts(caseCountsMap map[string]CountryWithStates, fromIndex int, toIndex int) map[string]CountryWithStates {
+	filteredCaseCounts := make(map[string]CountryWithStates, len(caseCountsMap))
+	for country, countryInfo := range caseCountsMap {
+		newInfo := CountryWithStates{countryInfo.Name, make(map[string]CaseCounts, len(countryInfo.States))}
+		for state, stateInfo := range countryInfo.States {
+			newInfo.States[state] = CaseCounts{stateInfo.LocationAndPopulation, stateInfo.Counts[fromIndex : toIndex+1]}
+		}
+		filteredCaseCounts[country] = newInfo
+	}
+	return filteredCaseCounts
+}
+
+func getHeapInUse() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapAlloc
+}
+
+func BenchmarkStorageMemory_Maps(b *testing.B) {
+	var caseCountsMap map[string]CountryWithStates
+	for i := 0; i < b.N; i++ {
+		before := getHeapInUse()
+		caseCountsMap, _ = getBenchmarkCaseCounts()
+		b.ReportMetric(float64(getHeapInUse()-before), "heap-bytes")
+	}
+	runtime.KeepAlive(caseCountsMap)
+}
+
+func BenchmarkStorageMemory_Columnar(b *testing.B) {
+	caseCountsMap, headerRow := getBenchmarkCaseCounts()
+	var dataset *Dataset
+	for i := 0; i < b.N; i++ {
+		dataset = nil
+		before := getHeapInUse()
+		dataset = newDataset(toCountrySeriesMap(caseCountsMap), headerRow)
+		b.ReportMetric(float64(getHeapInUse()-before), "heap-bytes")
+	}
+	runtime.KeepAlive(caseCountsMap)
+	runtime.KeepAlive(dataset)
+}
+
+func BenchmarkAggregateDataBetweenDates_Maps(b *testing.B) {
+	caseCountsMap, _ := getBenchmarkCaseCounts()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mapAggregateDataBetweenDates(caseCountsMap, 100, 900)
+	}
+}
+
+func BenchmarkAggregateDataBetweenDates_Columnar(b *testing.B) {
+	caseCountsMap, headerRow := getBenchmarkCaseCounts()
+	dataset := newDataset(toCountrySeriesMap(caseCountsMap), headerRow)
+	from, to := headerRow[4+100], headerRow[4+900]
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dataset.aggregateDataBetweenDates(from, to, "", nil)
+	}
+}
+
+func BenchmarkFilterCaseCounts_Maps(b *testing.B) {
+	caseCountsMap, _ := getBenchmarkCaseCounts()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mapFilterCaseCounts(caseCountsMap, 100, 900)
+	}
+}
+
+func BenchmarkFilterCaseCounts_Columnar(b *testing.B) {
+	caseCountsMap, headerRow := getBenchmarkCaseCounts()
+	dataset := newDataset(toCountrySeriesMap(caseCountsMap), headerRow)
+	from, to := headerRow[4+100], headerRow[4+900]
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dataset.filterCaseCounts(from, to, "", nil, "", "")
+	}
+}
+
+func BenchmarkPerDayResponse_Maps(b *testing.B) {
+	caseCountsMap, _ := getBenchmarkCaseCounts()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(mapFilterCaseCounts(caseCountsMap, 100, 900))
+	}
+}
+
+func BenchmarkPerDayResponse_Columnar(b *testing.B) {
+	caseCountsMap, headerRow := getBenchmarkCaseCounts()
+	dataset := newDataset(toCountrySeriesMap(caseCountsMap), headerRow)
+	from, to := headerRow[4+100], headerRow[4+900]
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		filtered, _ := dataset.filterCaseCounts(from, to, "", nil, "", "")
+		json.Marshal(filtered)
+	}
+}
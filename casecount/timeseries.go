@@ -0,0 +1,61 @@
+package casecount
+
+func newTimeSeries(numDays int) timeSeries {
+	return timeSeries{make([]int32, numDays), make([]int32, numDays), make([]int32, numDays)}
+}
+
+func (s timeSeries) length() int {
+	return len(s.confirmed)
+}
+
+func (s timeSeries) statisticsAt(index int) statistics {
+	return statistics{int(s.confirmed[index]), int(s.deaths[index]), int(s.recovered[index])}
+}
+
+// add : add the counts of other to the counts of s for every day, both series must have the same length
+func (s timeSeries) add(other timeSeries) {
+	for i := range s.confirmed {
+		s.confirmed[i] += other.confirmed[i]
+		s.deaths[i] += other.deaths[i]
+		s.recovered[i] += other.recovered[i]
+	}
+}
+
+// copy : get a time series with the same counts that does not share memory with s
+func (s timeSeries) copy() timeSeries {
+	result := newTimeSeries(s.length())
+	result.add(s)
+	return result
+}
+
+// getStatisticsSum : get the number of new confirmed cases/deaths/recoveries between fromIndex and toIndex inclusive
+func (s timeSeries) getStatisticsSum(fromIndex int, toIndex int) statistics {
+	if fromIndex >= s.length() || toIndex < 0 {
+		return statistics{}
+	}
+	if toIndex >= s.length() {
+		toIndex = s.length() - 1
+	}
+	result := s.statisticsAt(toIndex)
+	if fromIndex > 0 {
+		atStartDate := s.statisticsAt(fromIndex - 1)
+		result.Confirmed -= atStartDate.Confirmed
+		result.Deaths -= atStartDate.Deaths
+		result.Recovered -= atStartDate.Recovered
+	}
+	return result
+}
+
+// toCaseCounts : convert the periods into the per day shape used in responses, with the cumulative counts at the end of each period.
+// Periods longer than a day also contain the number of new cases during the period.
+func (s timeSeries) toCaseCounts(dates []string, periods []period) []CaseCount {
+	counts := make([]CaseCount, 0, len(periods))
+	for _, p := range periods {
+		count := CaseCount{dates[p.toIndex], s.statisticsAt(p.toIndex), nil}
+		if p.label != "" {
+			count.Period = &Period{p.label, s.getStatisticsSum(p.fromIndex, p.toIndex)}
+		}
+		counts = append(counts, count)
+	}
+	return counts
+}
@@ -6,10 +6,17 @@ type statistics struct {
 	Recovered int `json:"recovered"`
 }
 
-// CaseCount : contains stattics for given date
+// Period : label of the week or month that a count covers and the number of new confirmed cases/deaths/recoveries during it
+type Period struct {
+	Period string     `json:"period"`
+	New    statistics `json:"new"`
+}
+
+// CaseCount : contains stattics for given date, for weekly or monthly counts the date is the last day of the period
 type CaseCount struct {
 	Date string `json:"date"`
 	statistics
+	*Period
 }
 
 // LocationAndPopulation : point coordinates in the world map and population of state/country
@@ -54,3 +61,50 @@ type CountryAggregated struct {
 	Name string `json:"country"`
 	CaseCountsAggregated
 }
+
+// timeSeries : cumulative statistics of a location stored column by column, where index i of each column is the i-th day of the dataset's date axis
+type timeSeries struct {
+	confirmed []int32
+	deaths    []int32
+	recovered []int32
+}
+
+// locationSeries : point coordinates and population of a state or country together with its time series
+type locationSeries struct {
+	LocationAndPopulation
+	timeSeries
+}
+
+// countrySeries : name and per state time series of a country, as well as the time series of the country as a whole
+type countrySeries struct {
+	name   string
+	states map[string]*locationSeries
+	total  locationSeries
+}
+
+// DateRange : first and last dates that a location has reported cases, deaths or recoveries for
+type DateRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// StateMetadata : codes, point coordinates and population of a state and the range of dates that it has data for
+type StateMetadata struct {
+	UID  string `json:"uid,omitempty"`
+	ISO2 string `json:"iso2,omitempty"`
+	FIPS string `json:"fips,omitempty"`
+	LocationAndPopulation
+	DateRange *DateRange `json:"dateRange,omitempty"`
+}
+
+// CountryMetadata : name, codes, point coordinates and population of a country and the range of dates that it has data for, together with its states
+type CountryMetadata struct {
+	Name  string `json:"country"`
+	ISO2  string `json:"iso2"`
+	ISO3  string `json:"iso3,omitempty"`
+	Code3 string `json:"code3,omitempty"`
+	UID   string `json:"uid,omitempty"`
+	LocationAndPopulation
+	DateRange *DateRange               `json:"dateRange,omitempty"`
+	States    map[string]StateMetadata `json:"states"`
+}
@@ -2,8 +2,20 @@ package casecount
 
 import (
 	"testing"
+
+	"yet-another-covid-map-api/utils"
 )
 
+func (a CaseCount) equals(b CaseCount) bool {
+	if a.Date != b.Date || a.statistics != b.statistics {
+		return false
+	}
+	if a.Period == nil || b.Period == nil {
+		return a.Period == b.Period
+	}
+	return *a.Period == *b.Period
+}
+
 func (a *CaseCounts) equals(b CaseCounts) bool {
 	if a.Population != b.Population || int(a.Lat) != int(b.Lat) || int(a.Long) != int(b.Long) {
 		return false
@@ -12,7 +24,7 @@ func (a *CaseCounts) equals(b CaseCounts) bool {
 		return false
 	}
 	for i, item := range a.Counts {
-		if item != b.Counts[i] {
+		if !item.equals(b.Counts[i]) {
 			return false
 		}
 	}
@@ -31,8 +43,8 @@ func (a *CaseCountsAggregated) equals(b CaseCountsAggregated) bool {
 
 func verifyResultsCaseCountArr(expectedData []CaseCount, result []CaseCount, t *testing.T) {
 	for i, item := range result {
-		if item != expectedData[i] {
-			t.Errorf("Result data is incorrect, got: %+v, want %+v.", item, expectedData[i])
+		if !item.equals(expectedData[i]) {
+			t.Errorf("Result data is incorrect, got: %+v %+v, want %+v %+v.", item, item.Period, expectedData[i], expectedData[i].Period)
 		}
 	}
 }
@@ -84,3 +96,59 @@ func verifyResultsCountryCaseCountsAgg(expectedData map[string]CountryAggregated
 		}
 	}
 }
+
+func toCountrySeriesMap(caseCountsMap map[string]CountryWithStates) map[string]*countrySeries {
+	countries := make(map[string]*countrySeries, len(caseCountsMap))
+	for country, countryInfo := range caseCountsMap {
+		states := make(map[string]*locationSeries, len(countryInfo.States))
+		for state, stateInfo := range countryInfo.States {
+			series := newTimeSeries(len(stateInfo.Counts))
+			for i, count := range stateInfo.Counts {
+				series.confirmed[i] = int32(count.Confirmed)
+				series.deaths[i] = int32(count.Deaths)
+				series.recovered[i] = int32(count.Recovered)
+			}
+			states[state] = &locationSeries{stateInfo.LocationAndPopulation, series}
+		}
+		countries[country] = &countrySeries{name: countryInfo.Name, states: states}
+	}
+	return countries
+}
+
+func toCaseCountsMap(countries map[string]*countrySeries, dates []string) map[string]CountryWithStates {
+	caseCountsMap := make(map[string]CountryWithStates, len(countries))
+	periods := make([]period, len(dates))
+	for i := range dates {
+		periods[i] = period{"", i, i}
+	}
+	for country, countryInfo := range countries {
+		caseCountsMap[country] = countryInfo.toCountryWithStates(dates, periods, nil)
+	}
+	return caseCountsMap
+}
+
+func streamTable(table [][]string, rowFn utils.CSVRowFn) error {
+	for rowIndex, row := range table {
+		if err := rowFn(rowIndex, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func ingestGlobalTables(confirmedData [][]string, deathsData [][]string, recoveredData [][]string) (map[string]*countrySeries, error) {
+	ing := newIngestion()
+	if err := streamTable(confirmedData, ing.addConfirmedRow); err != nil {
+		return nil, err
+	}
+	if err := streamTable(deathsData, ing.addDeathsRow); err != nil {
+		return nil, err
+	}
+	if err := ing.finishGlobalRows(); err != nil {
+		return nil, err
+	}
+	if err := streamTable(recoveredData, ing.addRecoveredRow); err != nil {
+		return nil, err
+	}
+	return ing.countries, nil
+}
@@ -1,6 +1,11 @@
 package dateformat
 
-import "time"
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
 
 const (
 	// CasesDateFormat : the date format used in the CSV file provided by John Hopkins CSSE
@@ -9,32 +14,108 @@ const (
 	NewsDateFormat = "2006-01-02"
 )
 
-var dateFormats []string = []string{
-	CasesDateFormat,
-	NewsDateFormat,
-	"1-2-06",
-	"01/02/06",
-	"01-02-06",
-	"01/02/2006",
-	"01-02-2006",
-	"06/01/02",
-	"06-1-2",
-	"06/1/2",
-	"2006-1-2",
-	"2006/1/2",
-	"06-01-02",
-	"06/01/02",
+const (
+	// FormatJHU : month first dates like the ones used by John Hopkins CSSE, such as 1/2/06 or 01-02-2006
+	FormatJHU = "jhu"
+	// FormatISO : ISO 8601 dates with a four digit year first, such as 2006-01-02
+	FormatISO = "iso"
+	// FormatUnix : number of seconds since 1970-01-01 UTC
+	FormatUnix = "unix"
+	// FormatYearFirst : two digit year first dates, such as 06/01/02. Only accepted as an input format
+	FormatYearFirst = "ymd"
+)
+
+// InputFormats : the formats that input dates can be given in
+var InputFormats = []string{FormatISO, FormatJHU, FormatYearFirst, FormatUnix}
+
+// detectedFormats : the input formats in the order they are tried when the input format is not given. FormatJHU comes first, so that a
+// date such as 03/01/20 that is also a valid FormatYearFirst date is month first like the dates of John Hopkins CSSE
+var detectedFormats = []string{FormatJHU, FormatISO, FormatYearFirst, FormatUnix}
+
+// OutputFormats : the formats that dates in responses can be given in
+var OutputFormats = []string{FormatJHU, FormatISO, FormatUnix}
+
+// inputDateLayouts : layouts for each input format. Single digit layouts also accept leading zeros, so 1/2/06 matches 01/02/06 as well
+var inputDateLayouts = map[string][]string{
+	FormatISO:       []string{"2006-1-2", "2006/1/2"},
+	FormatJHU:       []string{"1/2/06", "1-2-06", "1/2/2006", "1-2-2006"},
+	FormatYearFirst: []string{"06/1/2", "06-1-2"},
 }
 
-// FormatDate : parse and format date into formatTo
-func FormatDate(formatTo string, date string) (string, bool) {
-	if date == "" {
-		return "", true
+var unixDateRegex = regexp.MustCompile(`^\d+$`)
+
+// minUnixSeconds : earliest unix date that is accepted, 2000-01-01, so that compact dates such as 20200301 are not taken as seconds in 1970
+const minUnixSeconds = 946684800
+
+// IsValidOutputFormat : whether outputFormat is one of OutputFormats, an empty format means FormatJHU
+func IsValidOutputFormat(outputFormat string) bool {
+	return outputFormat == "" || contains(OutputFormats, outputFormat)
+}
+
+func contains(formats []string, format string) bool {
+	for _, item := range formats {
+		if item == format {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDateWithFormat(inputFormat string, date string) (time.Time, bool) {
+	if inputFormat == FormatUnix {
+		if !unixDateRegex.MatchString(date) {
+			return time.Time{}, false
+		}
+		seconds, err := strconv.ParseInt(date, 10, 64)
+		if err != nil || seconds < minUnixSeconds {
+			return time.Time{}, false
+		}
+		return time.Unix(seconds, 0).UTC().Truncate(24 * time.Hour), true
+	}
+	for _, layout := range inputDateLayouts[inputFormat] {
+		if parsedDate, err := time.Parse(layout, date); err == nil {
+			return parsedDate, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseDate : parse date in inputFormat. If inputFormat is empty the input formats are tried in the order of detectedFormats, so a date
+// that is valid in more than one format, such as 01/02/03, is parsed as a month first FormatJHU date
+func ParseDate(inputFormat string, date string) (time.Time, error) {
+	if inputFormat != "" {
+		if parsedDate, ok := parseDateWithFormat(inputFormat, date); ok {
+			return parsedDate, nil
+		}
+		return time.Time{}, fmt.Errorf("Date %s is not in the %s format", date, inputFormat)
 	}
-	for _, format := range dateFormats {
-		if formattedDate, err := time.Parse(format, date); err == nil {
-			return formattedDate.Format(formatTo), true
+	for _, format := range detectedFormats {
+		if parsedDate, ok := parseDateWithFormat(format, date); ok {
+			return parsedDate, nil
 		}
 	}
-	return "", false
+	return time.Time{}, fmt.Errorf("Date format is not recognised for %s, please use either YYYY-MM-DD, YYYY/MM/DD, MM-DD-YY or MM/DD/YY, or a date relative to the latest date such as latest, latest-1w or -14d", date)
+}
+
+// FormatDate : parse date in inputFormat and format it into formatTo
+func FormatDate(formatTo string, inputFormat string, date string) (string, error) {
+	if date == "" {
+		return "", nil
+	}
+	parsedDate, err := ParseDate(inputFormat, date)
+	if err != nil {
+		return "", err
+	}
+	return parsedDate.Format(formatTo), nil
+}
+
+// FormatOutputDate : format date into one of OutputFormats for responses
+func FormatOutputDate(outputFormat string, date time.Time) string {
+	switch outputFormat {
+	case FormatISO:
+		return date.Format(NewsDateFormat)
+	case FormatUnix:
+		return strconv.FormatInt(date.Unix(), 10)
+	}
+	return date.Format(CasesDateFormat)
 }
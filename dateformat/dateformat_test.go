@@ -2,6 +2,7 @@ package dateformat
 
 import (
 	"testing"
+	"time"
 )
 
 type testStruct struct {
@@ -35,11 +36,17 @@ func TestGetCasesDateFormat(t *testing.T) {
 		{"", "", true},
 		{"2020-01-32", "", false},
 		{"13/28/20", "", false},
+		{"02-01-2020", "2/1/20", true},
+		{"20/1/31", "1/31/20", true},
+		{"03/01/20", "3/1/20", true},
+		{"01/02/03", "1/2/03", true},
+		{"1577836800", "1/1/20", true},
+		{"20200301", "", false},
 	}
 
 	for _, table := range tables {
-		result, err := FormatDate(CasesDateFormat, table.input)
-		verifyResult(table, result, err, t)
+		result, err := FormatDate(CasesDateFormat, "", table.input)
+		verifyResult(table, result, err == nil, t)
 	}
 }
 
@@ -53,7 +60,55 @@ func TestGetNewsDateFormat(t *testing.T) {
 	}
 
 	for _, table := range tables {
-		result, ok := FormatDate(NewsDateFormat, table.input)
-		verifyResult(table, result, ok, t)
+		result, err := FormatDate(NewsDateFormat, "", table.input)
+		verifyResult(table, result, err == nil, t)
+	}
+}
+
+func TestFormatDate_InputFormat(t *testing.T) {
+	tables := []struct {
+		inputFormat string
+		testStruct
+	}{
+		{FormatJHU, testStruct{"02-01-20", "2/1/20", true}},
+		{FormatYearFirst, testStruct{"02-01-20", "1/20/02", true}},
+		{FormatJHU, testStruct{"01/02/03", "1/2/03", true}},
+		{FormatYearFirst, testStruct{"01/02/03", "2/3/01", true}},
+		{FormatISO, testStruct{"2020-01-02", "1/2/20", true}},
+		{FormatISO, testStruct{"1/2/20", "", false}},
+		{FormatUnix, testStruct{"1577923200", "1/2/20", true}},
+		{FormatUnix, testStruct{"2020-01-02", "", false}},
+		{FormatUnix, testStruct{"20200301", "", false}},
+		{FormatUnix, testStruct{"946684800", "1/1/00", true}},
+	}
+
+	for _, table := range tables {
+		result, err := FormatDate(CasesDateFormat, table.inputFormat, table.input)
+		verifyResult(table.testStruct, result, err == nil, t)
+	}
+}
+
+func TestFormatOutputDate(t *testing.T) {
+	date := time.Date(2020, 1, 22, 0, 0, 0, 0, time.UTC)
+	tables := []struct {
+		outputFormat string
+		expected     string
+	}{
+		{"", "1/22/20"},
+		{FormatJHU, "1/22/20"},
+		{FormatISO, "2020-01-22"},
+		{FormatUnix, "1579651200"},
+	}
+
+	for _, table := range tables {
+		if result := FormatOutputDate(table.outputFormat, date); result != table.expected {
+			t.Errorf("Result was incorrect for %s, got: %s, want: %s.", table.outputFormat, result, table.expected)
+		}
+	}
+}
+
+func TestIsValidFormat(t *testing.T) {
+	if !IsValidOutputFormat("") || !IsValidOutputFormat(FormatUnix) || IsValidOutputFormat(FormatYearFirst) {
+		t.Error("IsValidOutputFormat result was incorrect.")
 	}
 }
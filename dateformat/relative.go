@@ -0,0 +1,47 @@
+package dateformat
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LatestDate : keyword for the latest date that there is data for
+const LatestDate = "latest"
+
+// relativeDateRegex : matches dates relative to the latest date, such as latest, latest-1w and -14d
+var relativeDateRegex = regexp.MustCompile(`^(latest)?(?:-(\d+)([dw]))?$`)
+
+// IsRelativeDate : whether date is relative to the latest date instead of an absolute date
+func IsRelativeDate(date string) bool {
+	return date != "" && relativeDateRegex.MatchString(strings.ToLower(date))
+}
+
+// ResolveRelativeDay : count back from latest to the day that a relative date refers to.
+// The offset can be given in days (d) or weeks (w), for example -14d is 14 days before latest and latest-1w is 7 days before latest
+func ResolveRelativeDay(date string, latest time.Time) (time.Time, bool) {
+	if date == "" {
+		return time.Time{}, false
+	}
+	match := relativeDateRegex.FindStringSubmatch(strings.ToLower(date))
+	if match == nil {
+		return time.Time{}, false
+	}
+	if match[2] == "" {
+		return latest, true
+	}
+	amount, err := strconv.Atoi(match[2])
+	if err != nil {
+		return time.Time{}, false
+	}
+	if match[3] == "w" {
+		amount *= 7
+	}
+	return latest.AddDate(0, 0, -amount), true
+}
+
+// ResolveLastDays : get the first date of the last numDays days up to and including latest, formatted into formatTo
+func ResolveLastDays(formatTo string, numDays int, latest time.Time) string {
+	return latest.AddDate(0, 0, -(numDays - 1)).Format(formatTo)
+}
@@ -0,0 +1,64 @@
+package dateformat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsRelativeDate(t *testing.T) {
+	tables := []struct {
+		input    string
+		expected bool
+	}{
+		{"latest", true},
+		{"Latest", true},
+		{"latest-1w", true},
+		{"-14d", true},
+		{"-2m", false},
+		{"", false},
+		{"14d", false},
+		{"-14y", false},
+		{"latest+1d", false},
+		{"1/2/20", false},
+		{"2020-01-02", false},
+	}
+	for _, table := range tables {
+		if result := IsRelativeDate(table.input); result != table.expected {
+			t.Errorf("IsRelativeDate result was incorrect for %s, got: %t, want: %t.", table.input, result, table.expected)
+		}
+	}
+}
+
+func TestResolveRelativeDay(t *testing.T) {
+	latest := time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)
+	tables := []testStruct{
+		{"latest", "3/31/20", true},
+		{"LATEST", "3/31/20", true},
+		{"-14d", "3/17/20", true},
+		{"latest-14d", "3/17/20", true},
+		{"latest-1w", "3/24/20", true},
+		{"-0d", "3/31/20", true},
+		{"-1m", "", false},
+		{"", "", false},
+		{"-14", "", false},
+		{"3/31/20", "", false},
+	}
+	for _, table := range tables {
+		day, ok := ResolveRelativeDay(table.input, latest)
+		result := ""
+		if ok {
+			result = day.Format(CasesDateFormat)
+		}
+		verifyResult(table, result, ok, t)
+	}
+}
+
+func TestResolveLastDays(t *testing.T) {
+	latest := time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC)
+	if result := ResolveLastDays(CasesDateFormat, 14, latest); result != "3/18/20" {
+		t.Errorf("Result was incorrect, got: %s, want: %s.", result, "3/18/20")
+	}
+	if result := ResolveLastDays(CasesDateFormat, 1, latest); result != "3/31/20" {
+		t.Errorf("Result was incorrect, got: %s, want: %s.", result, "3/31/20")
+	}
+}
@@ -17,6 +17,12 @@ func setupRoutes() {
 	http.Handle("/", http.FileServer(http.Dir("./static")))
 	http.HandleFunc("/cases", requests.GetCaseCounts)
 	http.HandleFunc("/news", requests.GetNewsForCountry)
+	http.HandleFunc("/countries", requests.GetCountries)
+	http.HandleFunc("/countries/", requests.GetCountry)
+	http.HandleFunc("/autocomplete", requests.GetAutocomplete)
+	http.HandleFunc("/timeline", requests.GetTimeline)
+	http.HandleFunc("/status", requests.GetStatus)
+	http.HandleFunc("/docs", requests.GetDocs)
 }
 
 func init() {
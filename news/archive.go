@@ -0,0 +1,174 @@
+package news
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"yet-another-covid-map-api/dateformat"
+)
+
+const (
+	archiveEnvironmentVar string = "NEWS_ARCHIVE_DIR"
+	// providerWindow : how far back the News API returns news on the free plan, so older news can only come from the archive
+	providerWindow = 30 * 24 * time.Hour
+	// worldArchiveKey : directory of the news that was not fetched for a country
+	worldArchiveKey = "world"
+	// maxArchiveDays : most days that are read from the archive for a query, so that a very long range cannot read years of files
+	maxArchiveDays = 366
+)
+
+// newsArchive : every article that was fetched for the default search, stored as one JSON file for each language, country and day that it was
+// published on, such as en/sg/2020-04-02.json, so that news older than the window of the providers can still be returned
+type newsArchive struct {
+	mux sync.Mutex
+	dir string
+	now func() time.Time
+}
+
+// archive : nil if NEWS_ARCHIVE_DIR is not set, in which case news is not archived
+var archive = getArchive()
+
+func newNewsArchive(dir string) *newsArchive {
+	return &newsArchive{dir: dir, now: time.Now}
+}
+
+// getArchive : get the archive in the absolute path of the directory in its environment variable, or nil if it is not set or cannot be resolved
+func getArchive() *newsArchive {
+	dir := os.Getenv(archiveEnvironmentVar)
+	if dir == "" {
+		log.Println("News is not archived, please set the " + archiveEnvironmentVar + " environment variable to the directory to archive it in.")
+		return nil
+	}
+	absoluteDir, err := filepath.Abs(dir)
+	if err != nil {
+		log.Printf("News is not archived, %s %s cannot be resolved: %s\n", archiveEnvironmentVar, dir, err.Error())
+		return nil
+	}
+	return newNewsArchive(absoluteDir)
+}
+
+// getArchiveKey : directory of the news for the country of the query
+func getArchiveKey(country string) string {
+	if country == "" {
+		return worldArchiveKey
+	}
+	return strings.ToLower(country)
+}
+
+// isDefaultSearch : whether the query searches the headlines for the coronavirus keywords, which is the only search that is archived so that
+// the searches of clients cannot grow the archive without bound
+func isDefaultSearch(query Query) bool {
+	return query.Keywords == "" && query.SortBy == ""
+}
+
+// getPath : file of the news for the language and country of the query that was published on day
+func (a *newsArchive) getPath(query Query, day time.Time) string {
+	return filepath.Join(a.dir, query.Language, getArchiveKey(query.Country), day.Format(dateformat.NewsDateFormat)+".json")
+}
+
+// isBeforeWindow : whether date is before the oldest day that the providers return news for. Dates that are empty or not valid are not
+func (a *newsArchive) isBeforeWindow(date string) bool {
+	if date == "" {
+		return false
+	}
+	day, err := dateformat.ParseDate("", date)
+	if err != nil {
+		return false
+	}
+	return day.Before(a.now().UTC().Add(-providerWindow).Truncate(24 * time.Hour))
+}
+
+// read : get the archived articles of a file, which are none if it does not exist
+func (a *newsArchive) read(path string) ([]Article, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var articles []Article
+	err = json.Unmarshal(data, &articles)
+	return articles, err
+}
+
+// write : replace the articles of a file, writing them to a temporary file first so that the file is never partly written
+func (a *newsArchive) write(path string, articles []Article) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(articles)
+	if err != nil {
+		return err
+	}
+	tempPath := path + ".tmp"
+	if err := ioutil.WriteFile(tempPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tempPath, path)
+}
+
+// store : add the articles fetched for the query to the files of the days that they were published on, skipping the ones that are already
+// archived with the same URL and the ones without a valid publication date. Nothing is stored for other searches than the default one
+func (a *newsArchive) store(query Query, articles []Article) error {
+	if !isDefaultSearch(query) {
+		return nil
+	}
+	days := make(map[string][]Article)
+	for _, article := range articles {
+		publishedAt, ok := parsePublishedAt(article.PublishedAt)
+		if !ok {
+			continue
+		}
+		path := a.getPath(query, publishedAt.UTC())
+		article.Alternates = nil
+		days[path] = append(days[path], article)
+	}
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	for path, dayArticles := range days {
+		archived, err := a.read(path)
+		if err != nil {
+			return err
+		}
+		merged := mergeArticles([][]Article{archived, dayArticles})
+		if len(merged) == len(archived) {
+			continue
+		}
+		if err := a.write(path, merged); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// get : get the archived articles for the language and country of the query that were published between its dates and have all of its keywords.
+// The range ends today if there is no to date, and at most maxArchiveDays are read from its start
+func (a *newsArchive) get(query Query) ([]Article, error) {
+	from, err := dateformat.ParseDate("", query.From)
+	if err != nil {
+		return nil, err
+	}
+	to := a.now().UTC().Truncate(24 * time.Hour)
+	if query.To != "" {
+		if to, err = dateformat.ParseDate("", query.To); err != nil {
+			return nil, err
+		}
+	}
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	var articles []Article
+	for day, count := from, 0; !day.After(to) && count < maxArchiveDays; day, count = day.AddDate(0, 0, 1), count+1 {
+		dayArticles, err := a.read(a.getPath(query, day))
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, dayArticles...)
+	}
+	return filterArticlesByKeywords(articles, query.Keywords), nil
+}
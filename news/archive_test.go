@@ -0,0 +1,208 @@
+package news
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setTestArchive : archive news in a temporary directory on 2020-05-15, returning a function that removes it and restores the archive
+func setTestArchive(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "news-archive")
+	if err != nil {
+		t.Fatalf("Temporary directory could not be created: %s.", err.Error())
+	}
+	previousArchive := archive
+	archive = newNewsArchive(dir)
+	archive.now = func() time.Time {
+		return time.Date(2020, 5, 15, 12, 0, 0, 0, time.UTC)
+	}
+	return func() {
+		archive = previousArchive
+		os.RemoveAll(dir)
+	}
+}
+
+// countingProvider : a provider that records how many times it was called
+type countingProvider struct {
+	articles []Article
+	err      error
+	calls    *int
+}
+
+func (p countingProvider) Name() string {
+	return "counting"
+}
+
+func (p countingProvider) GetArticles(query Query) ([]Article, int, error) {
+	*p.calls++
+	return p.articles, len(p.articles), p.err
+}
+
+func getSortedURLs(articles []Article) []string {
+	urls := make([]string, len(articles))
+	for i, article := range articles {
+		urls[i] = article.URL
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func TestNewsArchive_StoreAndGet(t *testing.T) {
+	defer setTestArchive(t)()
+	query := Query{Country: "sg", Language: "en"}
+	articles := []Article{
+		Article{"A", "Cases rise", "", "url1", "", "2020-03-01T10:00:00Z", []Alternate{Alternate{"B", "Cases rise", "url4", "2020-03-01T11:00:00Z"}}},
+		Article{"A", "Schools close", "", "url2", "", "2020-03-02T23:30:00-02:00", nil},
+		Article{"A", "Undated", "", "url3", "", "", nil},
+	}
+	if err := archive.store(query, articles); err != nil {
+		t.Fatalf("Articles could not be archived: %s.", err.Error())
+	}
+	if err := archive.store(query, articles[:1]); err != nil {
+		t.Fatalf("Articles could not be archived again: %s.", err.Error())
+	}
+	if _, err := os.Stat(filepath.Join(archive.dir, "en", "sg", "2020-03-03.json")); err != nil {
+		t.Errorf("Article should be archived on the day it was published in UTC, got: %s.", err.Error())
+	}
+	tables := []struct {
+		query    Query
+		expected []string
+	}{
+		{Query{From: "2020-03-01", To: "2020-03-03", Country: "SG", Language: "en"}, []string{"url1", "url2"}},
+		{Query{From: "2020-03-02", Country: "sg", Language: "en"}, []string{"url2"}},
+		{Query{From: "2020-03-01", To: "2020-03-01", Country: "sg", Language: "en"}, []string{"url1"}},
+		{Query{From: "2020-03-01", Country: "sg", Keywords: "schools", Language: "en"}, []string{"url2"}},
+		{Query{From: "2020-03-01", Country: "us", Language: "en"}, []string{}},
+		{Query{From: "2020-03-01", Country: "sg", Language: "fr"}, []string{}},
+	}
+	for _, table := range tables {
+		result, err := archive.get(table.query)
+		if urls := getSortedURLs(result); err != nil || strings.Join(urls, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Archived articles are incorrect for %+v, got: %v %v, want: %v.", table.query, urls, err, table.expected)
+		}
+		for _, article := range result {
+			if article.Alternates != nil {
+				t.Errorf("Alternates should not be archived, got: %+v.", article)
+			}
+		}
+	}
+}
+
+func TestNewsArchive_OnlyDefaultSearchIsStored(t *testing.T) {
+	defer setTestArchive(t)()
+	stores := []struct {
+		query    Query
+		articles []Article
+	}{
+		{Query{Country: "sg", Language: "en"}, []Article{Article{"A", "Cases rise", "", "url1", "", "2020-03-01T10:00:00Z", nil}}},
+		{Query{Country: "sg", Keywords: "Football", Language: "en"}, []Article{Article{"A", "Football league suspended", "", "url2", "", "2020-03-01T11:00:00Z", nil}}},
+		{Query{Country: "sg", Language: "en", SortBy: "popularity"}, []Article{Article{"A", "Popular story", "", "url3", "", "2020-03-01T12:00:00Z", nil}}},
+	}
+	for _, store := range stores {
+		if err := archive.store(store.query, store.articles); err != nil {
+			t.Fatalf("Articles could not be archived for %+v: %s.", store.query, err.Error())
+		}
+	}
+	tables := []struct {
+		query    Query
+		expected []string
+	}{
+		{Query{From: "2020-03-01", Country: "sg", Language: "en"}, []string{"url1"}},
+		{Query{From: "2020-03-01", Country: "sg", Keywords: "football", Language: "en"}, []string{}},
+		{Query{From: "2020-03-01", Country: "sg", Keywords: "cases", Language: "en"}, []string{"url1"}},
+		{Query{From: "2020-03-01", Country: "sg", Language: "en", SortBy: "popularity"}, []string{"url1"}},
+	}
+	for _, table := range tables {
+		result, err := archive.get(table.query)
+		if urls := getSortedURLs(result); err != nil || strings.Join(urls, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Archived articles are incorrect for %+v, got: %v %v, want: %v.", table.query, urls, err, table.expected)
+		}
+	}
+	entries, err := ioutil.ReadDir(filepath.Join(archive.dir, "en", "sg"))
+	if err != nil || len(entries) != 1 || entries[0].Name() != "2020-03-01.json" {
+		t.Errorf("Only the default search should be archived, got: %v %v.", entries, err)
+	}
+}
+
+func TestGetArchive(t *testing.T) {
+	previousDir, isSet := os.LookupEnv(archiveEnvironmentVar)
+	defer func() {
+		if isSet {
+			os.Setenv(archiveEnvironmentVar, previousDir)
+		} else {
+			os.Unsetenv(archiveEnvironmentVar)
+		}
+	}()
+	os.Unsetenv(archiveEnvironmentVar)
+	if result := getArchive(); result != nil {
+		t.Errorf("News should not be archived without %s, got: %+v.", archiveEnvironmentVar, result)
+	}
+	os.Setenv(archiveEnvironmentVar, filepath.Join("news", "archive"))
+	if result := getArchive(); result == nil || !filepath.IsAbs(result.dir) || !strings.HasSuffix(result.dir, filepath.Join("news", "archive")) {
+		t.Errorf("Archive directory should be resolved to an absolute path, got: %+v.", result)
+	}
+}
+
+func TestNewsArchive_IsBeforeWindow(t *testing.T) {
+	defer setTestArchive(t)()
+	tables := []struct {
+		date     string
+		expected bool
+	}{
+		{"2020-03-01", true},
+		{"2020-04-14", true},
+		{"2020-04-15", false},
+		{"2020-05-15", false},
+		{"", false},
+		{"not a date", false},
+	}
+	for _, table := range tables {
+		if result := archive.isBeforeWindow(table.date); result != table.expected {
+			t.Errorf("Result of isBeforeWindow is incorrect for %s, got: %t, want: %t.", table.date, result, table.expected)
+		}
+	}
+}
+
+func TestGetArticlesFromProvidersAndArchive(t *testing.T) {
+	defer setTestArchive(t)()
+	oldArticle := Article{"A", "March story", "", "url-march", "", "2020-03-10T00:00:00Z", nil}
+	if err := archive.store(Query{Language: "en"}, []Article{oldArticle}); err != nil {
+		t.Fatalf("Articles could not be archived: %s.", err.Error())
+	}
+	calls := 0
+	recent := []Article{Article{"A", "May story", "", "url-may", "", "2020-05-14T00:00:00Z", nil}}
+	testProviders := []NewsProvider{countingProvider{recent, nil, &calls}}
+	tables := []struct {
+		query    Query
+		expected []string
+		calls    int
+	}{
+		{Query{From: "2020-05-01", Language: "en"}, []string{"url-may"}, 1},
+		{Query{From: "2020-03-01", To: "2020-03-31", Language: "en"}, []string{"url-march"}, 0},
+		{Query{From: "2020-03-01", Language: "en"}, []string{"url-march", "url-may"}, 1},
+	}
+	for _, table := range tables {
+		calls = 0
+		result, _, err := getArticlesFromProvidersAndArchive(testProviders, table.query)
+		if urls := getSortedURLs(result); err != nil || strings.Join(urls, ",") != strings.Join(table.expected, ",") || calls != table.calls {
+			t.Errorf("Articles are incorrect for %+v, got: %v %v with %d calls, want: %v with %d calls.", table.query, urls, err, calls, table.expected, table.calls)
+		}
+	}
+	if archived, _ := archive.get(Query{From: "2020-05-14", Language: "en"}); len(archived) != 1 || archived[0].URL != "url-may" {
+		t.Errorf("Fetched articles should be archived, got: %+v.", archived)
+	}
+
+	failing := []NewsProvider{countingProvider{nil, errors.New("down"), &calls}}
+	if result, _, err := getArticlesFromProvidersAndArchive(failing, Query{From: "2020-03-01", Language: "en"}); err != nil || len(result) != 2 {
+		t.Errorf("Archived articles should be returned when the providers fail, got: %v %v.", getSortedURLs(result), err)
+	}
+	if _, _, err := getArticlesFromProvidersAndArchive(failing, Query{From: "2020-05-01", Language: "en"}); err == nil {
+		t.Error("Error should be returned when the providers fail and nothing is archived.")
+	}
+}
@@ -0,0 +1,140 @@
+package news
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	cacheTTLEnvironmentVar string = "NEWS_CACHE_TTL"
+	// defaultCacheTTL : how long news is served from the cache before it is refreshed, which keeps the News API quota for a day
+	// within the limit of the free plan
+	defaultCacheTTL = 15 * time.Minute
+	// maxCacheEntries : number of queries whose news is cached, after which the least recently fetched are evicted
+	maxCacheEntries = 1000
+)
+
+// cacheEntry : a page of the news for a query and when it was fetched
+type cacheEntry struct {
+	page       Page
+	err        error
+	fetchedAt  time.Time
+	refreshing bool
+	// ready : closed when the first fetch of the query has finished
+	ready chan struct{}
+}
+
+// newsCache : pages of news keyed by query. News is fresh for ttl, after which it is still served while a refresh runs in the background,
+// so that only the first request for a query waits for the News API. If a refresh fails the last good news keeps being served
+type newsCache struct {
+	mux     sync.Mutex
+	ttl     time.Duration
+	entries map[string]*cacheEntry
+	now     func() time.Time
+}
+
+var cache = newNewsCache(getCacheTTL())
+
+func newNewsCache(ttl time.Duration) *newsCache {
+	return &newsCache{ttl: ttl, entries: make(map[string]*cacheEntry), now: time.Now}
+}
+
+// getCacheTTL : get the TTL of the cache from its environment variable, such as 30m or 1h, or the default if it is not set or not valid
+func getCacheTTL() time.Duration {
+	ttlStr := os.Getenv(cacheTTLEnvironmentVar)
+	if ttlStr == "" {
+		return defaultCacheTTL
+	}
+	ttl, err := time.ParseDuration(ttlStr)
+	if err != nil || ttl < 0 {
+		log.Printf("%s %s is not a valid duration, using %s instead\n", cacheTTLEnvironmentVar, ttlStr, defaultCacheTTL)
+		return defaultCacheTTL
+	}
+	return ttl
+}
+
+// getCacheKey : key identifying the page of the news for the query
+func getCacheKey(query Query) string {
+	return strings.Join([]string{query.Country, query.From, query.To, query.Keywords, query.Language, query.SortBy, strconv.Itoa(query.Page),
+		strconv.Itoa(query.PageSize)}, "|")
+}
+
+// get : get the news for key from the cache, calling fetchFn if it is not cached yet and refreshing it in the background if it is stale.
+// Concurrent calls for a key that is not cached yet wait for the same fetch. The articles of the page are shared, so they must not be modified
+func (c *newsCache) get(key string, fetchFn func() (Page, error)) (Page, error) {
+	c.mux.Lock()
+	entry, ok := c.entries[key]
+	if !ok {
+		entry = &cacheEntry{ready: make(chan struct{})}
+		c.entries[key] = entry
+		c.mux.Unlock()
+		return c.fetch(key, entry, fetchFn)
+	}
+	c.mux.Unlock()
+
+	<-entry.ready
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if entry.err != nil {
+		return Page{}, entry.err
+	}
+	if c.now().Sub(entry.fetchedAt) >= c.ttl && !entry.refreshing {
+		entry.refreshing = true
+		go c.refresh(key, entry, fetchFn)
+	}
+	return entry.page, nil
+}
+
+// fetch : fetch the news of an entry that is not cached yet, which is removed again if the fetch fails so that the next call retries it
+func (c *newsCache) fetch(key string, entry *cacheEntry, fetchFn func() (Page, error)) (Page, error) {
+	page, err := fetchFn()
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if err != nil {
+		delete(c.entries, key)
+		entry.err = err
+	} else {
+		entry.page, entry.fetchedAt = page, c.now()
+		c.evict()
+	}
+	close(entry.ready)
+	return page, err
+}
+
+// refresh : replace the news of a stale entry, keeping the stale news if the fetch fails
+func (c *newsCache) refresh(key string, entry *cacheEntry, fetchFn func() (Page, error)) {
+	page, err := fetchFn()
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	entry.refreshing = false
+	if err != nil {
+		log.Printf("Refreshing news for %s failed, continuing to serve cached news: %s\n", key, err.Error())
+		return
+	}
+	entry.page, entry.fetchedAt = page, c.now()
+}
+
+// evict : remove the least recently fetched entry while there are more than maxCacheEntries, must be called with the lock held
+func (c *newsCache) evict() {
+	for len(c.entries) > maxCacheEntries {
+		var oldestKey string
+		var oldest *cacheEntry
+		for key, entry := range c.entries {
+			if entry.fetchedAt.IsZero() {
+				// the first fetch is still running
+				continue
+			}
+			if oldest == nil || entry.fetchedAt.Before(oldest.fetchedAt) {
+				oldestKey, oldest = key, entry
+			}
+		}
+		if oldest == nil {
+			return
+		}
+		delete(c.entries, oldestKey)
+	}
+}
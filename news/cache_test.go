@@ -0,0 +1,196 @@
+package news
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock : time that only moves when the test advances it
+type fakeClock struct {
+	mux  sync.Mutex
+	time time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.time
+}
+
+func (c *fakeClock) advance(duration time.Duration) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.time = c.time.Add(duration)
+}
+
+func newTestCache(ttl time.Duration) (*newsCache, *fakeClock) {
+	clock := &fakeClock{time: time.Date(2020, 4, 2, 0, 0, 0, 0, time.UTC)}
+	testCache := newNewsCache(ttl)
+	testCache.now = clock.now
+	return testCache, clock
+}
+
+func getTestPage(title string) Page {
+	return getPage([]Article{Article{"source", title, "", "", "", "", nil}}, 1, DefaultPageSize)
+}
+
+// waitForRefresh : wait until the background refresh of key has finished
+func waitForRefresh(c *newsCache, key string, t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		c.mux.Lock()
+		refreshing := c.entries[key].refreshing
+		c.mux.Unlock()
+		if !refreshing {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("Refresh did not finish.")
+}
+
+func TestNewsCache_FreshEntryIsNotFetchedAgain(t *testing.T) {
+	testCache, clock := newTestCache(time.Minute)
+	var calls int32
+	fetchFn := func() (Page, error) {
+		atomic.AddInt32(&calls, 1)
+		return getTestPage("headline"), nil
+	}
+	testCache.get("sg", fetchFn)
+	clock.advance(59 * time.Second)
+	page, err := testCache.get("sg", fetchFn)
+	if err != nil || len(page.Articles) != 1 || page.Articles[0].Title != "headline" {
+		t.Errorf("Cached articles are incorrect, got: %+v %v, want: %+v.", page, err, getTestPage("headline"))
+	}
+	if calls != 1 {
+		t.Errorf("Number of fetches is incorrect, got: %d, want: %d.", calls, 1)
+	}
+	testCache.get("us", fetchFn)
+	if calls != 2 {
+		t.Errorf("Each key should be fetched, got: %d fetches, want: %d.", calls, 2)
+	}
+}
+
+func TestNewsCache_StaleEntryIsServedWhileRefreshing(t *testing.T) {
+	testCache, clock := newTestCache(time.Minute)
+	testCache.get("sg", func() (Page, error) { return getTestPage("old"), nil })
+	clock.advance(time.Minute)
+	release := make(chan struct{})
+	page, _ := testCache.get("sg", func() (Page, error) {
+		<-release
+		return getTestPage("new"), nil
+	})
+	if page.Articles[0].Title != "old" {
+		t.Errorf("Stale articles should be served while refreshing, got: %s, want: %s.", page.Articles[0].Title, "old")
+	}
+	// only one refresh runs at a time
+	page, _ = testCache.get("sg", func() (Page, error) {
+		t.Error("A second refresh should not be started.")
+		return Page{}, nil
+	})
+	if page.Articles[0].Title != "old" {
+		t.Errorf("Stale articles should be served while refreshing, got: %s, want: %s.", page.Articles[0].Title, "old")
+	}
+	close(release)
+	waitForRefresh(testCache, "sg", t)
+	if page, _ := testCache.get("sg", nil); page.Articles[0].Title != "new" {
+		t.Errorf("Refreshed articles should be served, got: %s, want: %s.", page.Articles[0].Title, "new")
+	}
+}
+
+func TestNewsCache_LastGoodEntryIsServedWhenRefreshFails(t *testing.T) {
+	testCache, clock := newTestCache(time.Minute)
+	testCache.get("sg", func() (Page, error) { return getTestPage("good"), nil })
+	clock.advance(2 * time.Minute)
+	page, err := testCache.get("sg", func() (Page, error) { return Page{}, errors.New("quota exceeded") })
+	if err != nil || page.Articles[0].Title != "good" {
+		t.Errorf("Last good articles should be served, got: %+v %v.", page, err)
+	}
+	waitForRefresh(testCache, "sg", t)
+	if page, err := testCache.get("sg", func() (Page, error) { return Page{}, errors.New("quota exceeded") }); err != nil || page.Articles[0].Title != "good" {
+		t.Errorf("Last good articles should still be served after the refresh failed, got: %+v %v.", page, err)
+	}
+	waitForRefresh(testCache, "sg", t)
+}
+
+func TestNewsCache_FailedFirstFetchIsNotCached(t *testing.T) {
+	testCache, _ := newTestCache(time.Minute)
+	if _, err := testCache.get("sg", func() (Page, error) { return Page{}, errors.New("failure") }); err == nil {
+		t.Error("Error should be returned when there is nothing cached.")
+	}
+	page, err := testCache.get("sg", func() (Page, error) { return getTestPage("headline"), nil })
+	if err != nil || len(page.Articles) != 1 {
+		t.Errorf("Fetch should be retried after a failure, got: %+v %v.", page, err)
+	}
+}
+
+func TestNewsCache_ConcurrentMissesFetchOnce(t *testing.T) {
+	testCache, _ := newTestCache(time.Minute)
+	var calls int32
+	release := make(chan struct{})
+	fetchFn := func() (Page, error) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return getTestPage("headline"), nil
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if page, err := testCache.get("sg", fetchFn); err != nil || len(page.Articles) != 1 {
+				t.Errorf("Articles are incorrect, got: %+v %v.", page, err)
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("Number of fetches is incorrect, got: %d, want: %d.", calls, 1)
+	}
+}
+
+func TestNewsCache_EvictsLeastRecentlyFetched(t *testing.T) {
+	testCache, clock := newTestCache(time.Hour)
+	for i := 0; i <= maxCacheEntries; i++ {
+		testCache.get(strconv.Itoa(i), func() (Page, error) { return getTestPage("headline"), nil })
+		clock.advance(time.Second)
+	}
+	if len(testCache.entries) != maxCacheEntries {
+		t.Errorf("Number of entries is incorrect, got: %d, want: %d.", len(testCache.entries), maxCacheEntries)
+	}
+	if _, ok := testCache.entries["0"]; ok {
+		t.Error("Least recently fetched entry should have been evicted.")
+	}
+}
+
+func TestGetCacheTTL(t *testing.T) {
+	defer os.Unsetenv(cacheTTLEnvironmentVar)
+	tables := []struct {
+		ttl      string
+		expected time.Duration
+	}{
+		{"", defaultCacheTTL},
+		{"1h", time.Hour},
+		{"30s", 30 * time.Second},
+		{"soon", defaultCacheTTL},
+		{"-1m", defaultCacheTTL},
+	}
+	for _, table := range tables {
+		os.Setenv(cacheTTLEnvironmentVar, table.ttl)
+		if result := getCacheTTL(); result != table.expected {
+			t.Errorf("TTL is incorrect for %s, got: %s, want: %s.", table.ttl, result, table.expected)
+		}
+	}
+}
+
+func TestGetCacheKey(t *testing.T) {
+	if getCacheKey(Query{From: "2020-01-02", Country: "sg"}) == getCacheKey(Query{To: "2020-01-02", Country: "sg"}) {
+		t.Error("Keys of different queries should be different.")
+	}
+}
@@ -0,0 +1,166 @@
+package news
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"yet-another-covid-map-api/utils"
+)
+
+const (
+	// minTokenSimilarity : share of the words of two headlines that must be the same for them to be the same story
+	minTokenSimilarity = 0.75
+	// minEditSimilarity : similarity by edit distance of two headlines above which they are the same story, which allows for small
+	// differences in punctuation and wording between the sites that syndicate a story
+	minEditSimilarity = 0.85
+)
+
+// negationWords : words that reverse the meaning of a headline, such as "Vaccine is not effective"
+var negationWords = map[string]bool{"no": true, "not": true, "never": true, "none": true, "nobody": true, "nothing": true, "without": true, "cannot": true}
+
+// headline : the normalised title of an article that near duplicates are found by, with the numbers and the negations in it in order
+type headline struct {
+	text      string
+	tokens    map[string]bool
+	numbers   string
+	negations string
+}
+
+// newHeadline : get the headline of an article in lower case, without the name of its source that is often added to the end of its title
+func newHeadline(article Article) headline {
+	text := strings.ToLower(strings.TrimSpace(article.Title))
+	if source := strings.ToLower(strings.TrimSpace(article.Source)); source != "" {
+		for _, separator := range []string{" - ", " | ", " — "} {
+			text = strings.TrimSpace(strings.TrimSuffix(text, separator+source))
+		}
+	}
+	tokens := make(map[string]bool)
+	for _, token := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		tokens[token] = true
+	}
+	numbers := strings.Join(strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsDigit(r) }), " ")
+	return headline{text, tokens, numbers, getNegations(text)}
+}
+
+// getNegations : get the negation words of a headline in order, where contractions such as isn't are not
+func getNegations(text string) string {
+	var negations []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' && r != '’' }) {
+		if strings.HasSuffix(word, "n't") || strings.HasSuffix(word, "n’t") {
+			negations = append(negations, "not")
+		} else if negationWords[word] {
+			negations = append(negations, word)
+		}
+	}
+	return strings.Join(negations, " ")
+}
+
+// getTokenSimilarity : number of words that are in both headlines relative to the number of words that are in either
+func getTokenSimilarity(first headline, second headline) float64 {
+	if len(first.tokens) == 0 || len(second.tokens) == 0 {
+		return 0
+	}
+	shared := 0
+	for token := range first.tokens {
+		if second.tokens[token] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(first.tokens)+len(second.tokens)-shared)
+}
+
+// isNearDuplicate : whether two headlines are the same story, because most of their words are the same or they are only a few edits apart.
+// Headlines with different numbers, such as the number of new cases, or different negations, such as "no new cases", are different stories
+// however similar they are. Headlines whose lengths
+// are too different to be within minEditSimilarity are not compared by edit distance, which is slower
+func isNearDuplicate(first headline, second headline) bool {
+	if first.text == "" || second.text == "" {
+		return false
+	}
+	if first.text == second.text {
+		return true
+	}
+	if first.numbers != second.numbers || first.negations != second.negations {
+		return false
+	}
+	if getTokenSimilarity(first, second) >= minTokenSimilarity {
+		return true
+	}
+	shorter, longer := len(first.text), len(second.text)
+	if shorter > longer {
+		shorter, longer = longer, shorter
+	}
+	if float64(shorter) < minEditSimilarity*float64(longer) {
+		return false
+	}
+	return utils.GetEditSimilarity(first.text, second.text) >= minEditSimilarity
+}
+
+// isPublishedBefore : whether the first publication date is before the second, where dates that cannot be parsed are after every other date
+func isPublishedBefore(first string, second string) bool {
+	firstDate, firstOk := parsePublishedAt(first)
+	secondDate, secondOk := parsePublishedAt(second)
+	if !firstOk || !secondOk {
+		return firstOk && !secondOk
+	}
+	return firstDate.Before(secondDate)
+}
+
+// collapseNearDuplicates : replace each group of articles with nearly identical headlines by the earliest published article of the group,
+// with the others as its alternates. The groups are in the order of their first article
+func collapseNearDuplicates(articles []Article) []Article {
+	var headlines []headline
+	var groups [][]Article
+	for _, article := range articles {
+		articleHeadline := newHeadline(article)
+		group := -1
+		for i := range headlines {
+			if isNearDuplicate(articleHeadline, headlines[i]) {
+				group = i
+				break
+			}
+		}
+		if group == -1 {
+			headlines = append(headlines, articleHeadline)
+			groups = append(groups, []Article{article})
+		} else {
+			groups[group] = append(groups[group], article)
+		}
+	}
+
+	collapsed := make([]Article, len(groups))
+	for i, group := range groups {
+		sort.SliceStable(group, func(a, b int) bool {
+			return isPublishedBefore(group[a].PublishedAt, group[b].PublishedAt)
+		})
+		primary := group[0]
+		if len(group) > 1 {
+			primary.Alternates = make([]Alternate, 0, len(group)-1)
+			for _, article := range group[1:] {
+				primary.Alternates = append(primary.Alternates, Alternate{article.Source, article.Title, article.URL, article.PublishedAt})
+			}
+		}
+		collapsed[i] = primary
+	}
+	return collapsed
+}
+
+// sortByPublishedAt : sort the articles from the latest to the earliest published, with the ones without a valid date at the end
+func sortByPublishedAt(articles []Article) {
+	dates := make(map[string]time.Time, len(articles))
+	for _, article := range articles {
+		if date, ok := parsePublishedAt(article.PublishedAt); ok {
+			dates[article.PublishedAt] = date
+		}
+	}
+	sort.SliceStable(articles, func(a, b int) bool {
+		first, firstOk := dates[articles[a].PublishedAt]
+		second, secondOk := dates[articles[b].PublishedAt]
+		if !firstOk || !secondOk {
+			return firstOk && !secondOk
+		}
+		return first.After(second)
+	})
+}
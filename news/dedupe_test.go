@@ -0,0 +1,100 @@
+package news
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIsNearDuplicate(t *testing.T) {
+	tables := []struct {
+		first    Article
+		second   Article
+		expected bool
+	}{
+		{Article{Source: "Reuters", Title: "WHO declares coronavirus a pandemic - Reuters"}, Article{Source: "CNN", Title: "WHO declares coronavirus a pandemic | CNN"}, true},
+		{Article{Title: "WHO declares coronavirus outbreak a pandemic"}, Article{Title: "WHO declares the coronavirus outbreak a pandemic"}, true},
+		{Article{Title: "Singapore confirms 75 new COVID-19 cases"}, Article{Title: "Singapore confirms 75 new Covid-19 cases."}, true},
+		{Article{Title: "Singapore reports 75 new cases"}, Article{Title: "Singapore reports 120 new cases"}, false},
+		{Article{Title: "Stocks fall as virus spreads"}, Article{Title: "Schools close as virus spreads"}, false},
+		{Article{Title: "Singapore reports new coronavirus cases today"}, Article{Title: "Singapore reports no new coronavirus cases today"}, false},
+		{Article{Title: "Health ministry says the vaccine is effective"}, Article{Title: "Health ministry says the vaccine is not effective"}, false},
+		{Article{Title: "Health ministry says the vaccine is effective"}, Article{Title: "Health ministry says the vaccine isn't effective"}, false},
+		{Article{Title: "Health ministry says the vaccine isn’t effective"}, Article{Title: "Health ministry says the vaccine is not effective!"}, true},
+		{Article{Title: "No new coronavirus cases reported in Singapore"}, Article{Title: "No new coronavirus cases reported in Singapore."}, true},
+		{Article{Title: ""}, Article{Title: ""}, false},
+	}
+	for _, table := range tables {
+		if result := isNearDuplicate(newHeadline(table.first), newHeadline(table.second)); result != table.expected {
+			t.Errorf("Result of isNearDuplicate is incorrect for %s and %s, got: %t, want: %t.", table.first.Title, table.second.Title, result, table.expected)
+		}
+	}
+}
+
+func TestCollapseNearDuplicates(t *testing.T) {
+	articles := []Article{
+		Article{"CNN", "WHO declares coronavirus a pandemic | CNN", "", "https://cnn.example/pandemic", "", "2020-03-11T18:00:00Z", nil},
+		Article{"BBC", "Lockdown extended for two weeks", "", "https://bbc.example/lockdown", "", "2020-03-12T09:00:00Z", nil},
+		Article{"Reuters", "WHO declares coronavirus a pandemic - Reuters", "", "https://reuters.example/pandemic", "", "2020-03-11T16:30:00Z", nil},
+		Article{"AP", "WHO declares coronavirus a pandemic", "", "https://ap.example/pandemic", "", "", nil},
+	}
+	expected := []Article{
+		Article{"Reuters", "WHO declares coronavirus a pandemic - Reuters", "", "https://reuters.example/pandemic", "", "2020-03-11T16:30:00Z", []Alternate{
+			Alternate{"CNN", "WHO declares coronavirus a pandemic | CNN", "https://cnn.example/pandemic", "2020-03-11T18:00:00Z"},
+			Alternate{"AP", "WHO declares coronavirus a pandemic", "https://ap.example/pandemic", ""},
+		}},
+		Article{"BBC", "Lockdown extended for two weeks", "", "https://bbc.example/lockdown", "", "2020-03-12T09:00:00Z", nil},
+	}
+	result := collapseNearDuplicates(articles)
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Result of collapseNearDuplicates is incorrect, got: %+v, want: %+v.", result, expected)
+	}
+	if articles[0].Alternates != nil {
+		t.Errorf("Articles passed to collapseNearDuplicates should not be modified, got: %+v.", articles[0])
+	}
+}
+
+func TestSortByPublishedAt(t *testing.T) {
+	articles := []Article{
+		Article{"", "Undated", "", "", "", "", nil},
+		Article{"", "Earliest", "", "", "", "2020-03-30T12:00:00Z", nil},
+		Article{"", "Latest", "", "", "", "2020-04-02T01:30:00Z", nil},
+		Article{"", "Middle", "", "", "", "2020-04-01T09:00:00+08:00", nil},
+	}
+	sortByPublishedAt(articles)
+	expected := []string{"Latest", "Middle", "Earliest", "Undated"}
+	if result := getTitles(articles); strings.Join(result, ",") != strings.Join(expected, ",") {
+		t.Errorf("Result of sortByPublishedAt is incorrect, got: %v, want: %v.", result, expected)
+	}
+}
+
+func TestFetchArticles(t *testing.T) {
+	previousArchive := archive
+	defer func() { archive = previousArchive }()
+	archive = nil
+	testProviders := []NewsProvider{
+		fakeProvider{[]Article{
+			Article{"A", "Relevant story", "", "url1", "", "2020-04-01T00:00:00Z", nil},
+			Article{"A", "Newer story", "", "url2", "", "2020-04-02T00:00:00Z", nil},
+		}, 0, nil},
+		fakeProvider{[]Article{Article{"B", "Newer story", "", "url3", "", "2020-04-01T12:00:00Z", nil}}, 0, nil},
+	}
+	tables := []struct {
+		sortBy   string
+		expected []string
+	}{
+		{"", []string{"url3", "url1"}},
+		{"publishedAt", []string{"url3", "url1"}},
+		{"relevancy", []string{"url1", "url3"}},
+	}
+	for _, table := range tables {
+		page, err := fetchPage(testProviders, Query{SortBy: table.sortBy})
+		urls := make([]string, len(page.Articles))
+		for i, article := range page.Articles {
+			urls[i] = article.URL
+		}
+		if err != nil || strings.Join(urls, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Articles sorted by %q are incorrect, got: %v %v, want: %v.", table.sortBy, urls, err, table.expected)
+		}
+	}
+}
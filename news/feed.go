@@ -0,0 +1,391 @@
+package news
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"yet-another-covid-map-api/dateformat"
+)
+
+const (
+	feedsEnvironmentVar string = "NEWS_FEEDS_FILE"
+	mediaNamespace             = "http://search.yahoo.com/mrss/"
+)
+
+// countryFeeds : the RSS 2.0 and Atom feeds of each country by lower case iso2, the feeds under "" are used for every country
+var countryFeeds = map[string][]feedSource{}
+
+// publishedAtLayouts : layouts of the publication dates in RSS 2.0 and Atom feeds
+var publishedAtLayouts = []string{time.RFC3339, time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z, time.RFC822, "Mon, 2 Jan 2006 15:04 -0700", "Mon, 2 Jan 2006 15:04 MST", "2006-01-02T15:04:05", "2006-01-02"}
+
+// htmlTagPattern : tags in the titles and descriptions of feed items, which are often HTML
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// feedSource : a feed in the feeds configuration file, with the language that its items are in
+type feedSource struct {
+	URL      string `json:"url"`
+	Language string `json:"language"`
+}
+
+// feedsConfig : the feeds configuration file, with the feeds for every country and the feeds of each country by iso2
+type feedsConfig struct {
+	Global    []feedSource            `json:"global"`
+	Countries map[string][]feedSource `json:"countries"`
+}
+
+// mediaObject : an enclosure, a Media RSS thumbnail or content, or an Atom enclosure link
+type mediaObject struct {
+	URL    string `xml:"url,attr"`
+	Medium string `xml:"medium,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// mediaElements : the Media RSS elements of an item or entry. They come before the other fields of the item, because encoding/xml matches
+// elements to the first field with their name, and fields without a namespace such as title and description match every namespace
+type mediaElements struct {
+	MediaThumbnails  []mediaObject   `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	MediaContents    []mediaObject   `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaGroups      []mediaElements `xml:"http://search.yahoo.com/mrss/ group"`
+	MediaTitle       string          `xml:"http://search.yahoo.com/mrss/ title"`
+	MediaDescription string          `xml:"http://search.yahoo.com/mrss/ description"`
+}
+
+type rssItem struct {
+	mediaElements
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        string        `xml:"guid"`
+	Description string        `xml:"description"`
+	PubDate     string        `xml:"pubDate"`
+	Enclosures  []mediaObject `xml:"enclosure"`
+}
+
+type rssChannel struct {
+	Title string    `xml:"title"`
+	Items []rssItem `xml:"item"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type atomEntry struct {
+	mediaElements
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// feedDocument : an RSS 2.0 document, which has a channel, or an Atom document, which has a title and entries
+type feedDocument struct {
+	XMLName xml.Name
+	Channel rssChannel  `xml:"channel"`
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// feed : a single RSS 2.0 or Atom feed
+type feed struct {
+	url string
+}
+
+func (f feed) Name() string {
+	return "feed " + f.url
+}
+
+// GetArticles : get every item of the feed published between the dates of the query with all of its keywords, because feeds are not paged.
+// The feed is the same for every country and is only used for the language it is configured with, and its items are not sorted
+func (f feed) GetArticles(query Query) ([]Article, int, error) {
+	r, err := client.Get(f.url)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("Feed %s returned status %d", f.url, r.StatusCode)
+	}
+	articles, err := parseFeed(r.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Feed %s could not be parsed: %s", f.url, err.Error())
+	}
+	articles = filterArticlesByKeywords(filterArticlesBetweenDates(articles, query.From, query.To), query.Keywords)
+	return articles, len(articles), nil
+}
+
+// feedProvider : news from RSS 2.0 and Atom feeds configured for each country
+type feedProvider struct {
+	feeds map[string][]feedSource
+}
+
+func newFeedProvider(feeds map[string][]feedSource) feedProvider {
+	return feedProvider{feeds}
+}
+
+func (p feedProvider) Name() string {
+	return "RSS and Atom feeds"
+}
+
+// getFeeds : get the feeds for every country and the feeds of country that are in the language, which is the default language if it is empty
+func (p feedProvider) getFeeds(country string, language string) []NewsProvider {
+	if language == "" {
+		language = DefaultLanguage
+	}
+	sources := p.feeds[""]
+	if country != "" {
+		sources = append(append([]feedSource{}, sources...), p.feeds[strings.ToLower(country)]...)
+	}
+	var feeds []NewsProvider
+	for _, source := range sources {
+		if source.Language == strings.ToLower(language) {
+			feeds = append(feeds, feed{source.URL})
+		}
+	}
+	return feeds
+}
+
+// GetArticles : get the merged items of all the feeds for the country and language of the query that match it
+func (p feedProvider) GetArticles(query Query) ([]Article, int, error) {
+	articles, _, err := getArticlesFromProviders(p.getFeeds(query.Country, query.Language), query)
+	return articles, len(articles), err
+}
+
+// parseFeed : get the articles of an RSS 2.0 or Atom document
+func parseFeed(reader io.Reader) ([]Article, error) {
+	var document feedDocument
+	decoder := xml.NewDecoder(reader)
+	decoder.CharsetReader = getCharsetReader
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	switch document.XMLName.Local {
+	case "rss":
+		articles := make([]Article, 0, len(document.Channel.Items))
+		for _, item := range document.Channel.Items {
+			link := strings.TrimSpace(item.Link)
+			if link == "" && strings.HasPrefix(strings.TrimSpace(item.GUID), "http") {
+				link = strings.TrimSpace(item.GUID)
+			}
+			articles = append(articles, Article{cleanText(document.Channel.Title), cleanText(item.Title), cleanText(item.Description),
+				link, getThumbnailURL(item.mediaElements, item.Enclosures), formatPublishedAt(item.PubDate), nil})
+		}
+		return articles, nil
+	case "feed":
+		articles := make([]Article, 0, len(document.Entries))
+		for _, entry := range document.Entries {
+			description, publishedAt := entry.Summary, entry.Published
+			if description == "" {
+				description = entry.Content
+			}
+			if publishedAt == "" {
+				publishedAt = entry.Updated
+			}
+			articles = append(articles, Article{cleanText(document.Title), cleanText(entry.Title), cleanText(description),
+				getAtomLink(entry.Links), getThumbnailURL(entry.mediaElements, getAtomEnclosures(entry.Links)), formatPublishedAt(publishedAt), nil})
+		}
+		return articles, nil
+	}
+	return nil, fmt.Errorf("%s is not an RSS or Atom document", document.XMLName.Local)
+}
+
+// getCharsetReader : decode feeds that are not UTF-8. Only ISO-8859-1 and its subset ASCII are supported, which is what older feeds use
+func getCharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1":
+		data, err := ioutil.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		// every byte of ISO-8859-1 is the code point of its character
+		decoded := make([]byte, 0, len(data))
+		for _, b := range data {
+			decoded = append(decoded, string(rune(b))...)
+		}
+		return strings.NewReader(string(decoded)), nil
+	}
+	return nil, fmt.Errorf("charset %s is not supported", charset)
+}
+
+// cleanText : remove the HTML tags and entities from the title or description of a feed item and collapse its whitespace
+func cleanText(text string) string {
+	text = html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " "))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// isImage : whether a media object is an image by its medium or MIME type
+func isImage(media mediaObject) bool {
+	return media.Medium == "image" || strings.HasPrefix(strings.ToLower(media.Type), "image/")
+}
+
+// getThumbnailURL : get the thumbnail of a feed item, which is the first Media RSS thumbnail, else the first image in its Media RSS contents
+// or else its first image enclosure. Media RSS groups are checked after the elements of the item itself
+func getThumbnailURL(media mediaElements, enclosures []mediaObject) string {
+	for _, thumbnail := range media.MediaThumbnails {
+		if url := strings.TrimSpace(thumbnail.URL); url != "" {
+			return url
+		}
+	}
+	for _, content := range media.MediaContents {
+		if url := strings.TrimSpace(content.URL); url != "" && isImage(content) {
+			return url
+		}
+	}
+	for _, group := range media.MediaGroups {
+		if url := getThumbnailURL(group, nil); url != "" {
+			return url
+		}
+	}
+	for _, enclosure := range enclosures {
+		if url := strings.TrimSpace(enclosure.URL); url != "" && isImage(enclosure) {
+			return url
+		}
+	}
+	return ""
+}
+
+// getAtomEnclosures : get the enclosure links of an Atom entry
+func getAtomEnclosures(links []atomLink) []mediaObject {
+	var enclosures []mediaObject
+	for _, link := range links {
+		if link.Rel == "enclosure" {
+			enclosures = append(enclosures, mediaObject{link.Href, "", link.Type})
+		}
+	}
+	return enclosures
+}
+
+// loadCountryFeeds : load the feeds of each country from the feeds configuration file at path. No feeds are used if the file does not
+// exist, and the ones of a country whose iso2 is not known or without a URL and one of the Languages are skipped
+func loadCountryFeeds(path string) (map[string][]feedSource, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string][]feedSource{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var config feedsConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("Feeds configuration %s is not valid: %s", path, err.Error())
+	}
+	feeds := make(map[string][]feedSource)
+	if global := getValidFeedSources(config.Global, path); len(global) > 0 {
+		feeds[""] = global
+	}
+	for country, sources := range config.Countries {
+		country = strings.ToLower(strings.TrimSpace(country))
+		if len(country) != 2 {
+			log.Printf("Skipping the feeds of %s in %s, countries must be given by their iso2\n", country, path)
+			continue
+		}
+		if valid := getValidFeedSources(sources, path); len(valid) > 0 {
+			feeds[country] = append(feeds[country], valid...)
+		}
+	}
+	return feeds, nil
+}
+
+// getValidFeedSources : get the feeds that have a URL and one of the Languages, with their language in lower case
+func getValidFeedSources(sources []feedSource, path string) []feedSource {
+	var valid []feedSource
+	for _, source := range sources {
+		source.URL, source.Language = strings.TrimSpace(source.URL), strings.ToLower(strings.TrimSpace(source.Language))
+		if source.URL == "" || !isLanguage(source.Language) {
+			log.Printf("Skipping the feed %q in %s, each feed must have a url and one of the languages %v\n", source.URL, path, Languages)
+			continue
+		}
+		valid = append(valid, source)
+	}
+	return valid
+}
+
+// isLanguage : whether the language is one of the Languages
+func isLanguage(language string) bool {
+	for _, l := range Languages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// getFeedsFile : get the absolute path of the feeds configuration file in its environment variable, or an empty path if it is not set
+func getFeedsFile() string {
+	path := os.Getenv(feedsEnvironmentVar)
+	if path == "" {
+		return ""
+	}
+	if absolutePath, err := filepath.Abs(path); err == nil {
+		return absolutePath
+	}
+	return path
+}
+
+// getAtomLink : get the link to the article itself, which is the alternate link or the link without a rel
+func getAtomLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	return ""
+}
+
+// parsePublishedAt : parse the publication date of a feed item
+func parsePublishedAt(publishedAt string) (time.Time, bool) {
+	publishedAt = strings.TrimSpace(publishedAt)
+	for _, layout := range publishedAtLayouts {
+		if date, err := time.Parse(layout, publishedAt); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// formatPublishedAt : format the publication date of a feed item in UTC like the News API does, or leave it as it is if it cannot be parsed
+func formatPublishedAt(publishedAt string) string {
+	if date, ok := parsePublishedAt(publishedAt); ok {
+		return date.UTC().Format(time.RFC3339)
+	}
+	return strings.TrimSpace(publishedAt)
+}
+
+// filterArticlesBetweenDates : get the articles published on or between the days from and to, which can be empty.
+// Articles without a valid publication date are dropped if there is a date to filter by
+func filterArticlesBetweenDates(articles []Article, from string, to string) []Article {
+	if from == "" && to == "" {
+		return articles
+	}
+	fromDate, fromErr := dateformat.ParseDate("", from)
+	toDate, toErr := dateformat.ParseDate("", to)
+	var filtered []Article
+	for _, article := range articles {
+		publishedAt, ok := parsePublishedAt(article.PublishedAt)
+		if !ok {
+			continue
+		}
+		day := publishedAt.UTC().Truncate(24 * time.Hour)
+		if (from != "" && fromErr == nil && day.Before(fromDate)) || (to != "" && toErr == nil && day.After(toDate)) {
+			continue
+		}
+		filtered = append(filtered, article)
+	}
+	return filtered
+}
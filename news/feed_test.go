@@ -0,0 +1,268 @@
+package news
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testRSSFeed = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>Ministry of Health</title>
+    <item>
+      <title> Update on COVID-19 </title>
+      <link>https://moh.example/update</link>
+      <description>Local situation report</description>
+      <pubDate>Thu, 02 Apr 2020 09:30:00 +0800</pubDate>
+    </item>
+    <item>
+      <title>Older update</title>
+      <link>https://moh.example/older</link>
+      <description>Earlier report</description>
+      <pubDate>Mon, 30 Mar 2020 12:00:00 GMT</pubDate>
+    </item>
+  </channel>
+</rss>`
+
+const testAtomFeed = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>World Health Organization</title>
+  <entry>
+    <title>WHO statement</title>
+    <link rel="self" href="https://who.example/self"/>
+    <link rel="alternate" href="https://who.example/statement"/>
+    <content>Full statement</content>
+    <updated>2020-04-03T10:00:00Z</updated>
+  </entry>
+</feed>`
+
+type feedClient struct {
+	feeds map[string]string
+}
+
+func (c *feedClient) Get(url string) (*http.Response, error) {
+	feed, ok := c.feeds[url]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader([]byte(feed)))}, nil
+}
+
+// fixtureClient : serves the fixture feeds in testdata by URL
+type fixtureClient struct {
+	files map[string]string
+}
+
+func (c *fixtureClient) Get(url string) (*http.Response, error) {
+	file, ok := c.files[url]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func TestParseFeed_RSS(t *testing.T) {
+	articles, err := parseFeed(strings.NewReader(testRSSFeed))
+	expected := []Article{
+		Article{"Ministry of Health", "Update on COVID-19", "Local situation report", "https://moh.example/update", "", "2020-04-02T01:30:00Z", nil},
+		Article{"Ministry of Health", "Older update", "Earlier report", "https://moh.example/older", "", "2020-03-30T12:00:00Z", nil},
+	}
+	if err != nil || len(articles) != len(expected) {
+		t.Fatalf("Articles are incorrect, got: %+v %v, want: %+v.", articles, err, expected)
+	}
+	for i, article := range articles {
+		if !reflect.DeepEqual(article, expected[i]) {
+			t.Errorf("Article %d is incorrect, got: %+v, want: %+v.", i, article, expected[i])
+		}
+	}
+}
+
+func TestParseFeed_Atom(t *testing.T) {
+	articles, err := parseFeed(strings.NewReader(testAtomFeed))
+	expected := Article{"World Health Organization", "WHO statement", "Full statement", "https://who.example/statement", "", "2020-04-03T10:00:00Z", nil}
+	if err != nil || len(articles) != 1 || !reflect.DeepEqual(articles[0], expected) {
+		t.Errorf("Articles are incorrect, got: %+v %v, want: %+v.", articles, err, expected)
+	}
+}
+
+func TestParseFeed_Invalid(t *testing.T) {
+	for _, document := range []string{`<html><body></body></html>`, `<rss><channel>`} {
+		if _, err := parseFeed(strings.NewReader(document)); err == nil {
+			t.Errorf("Error should be returned for %s.", document)
+		}
+	}
+}
+
+func TestFilterArticlesBetweenDates(t *testing.T) {
+	articles := []Article{
+		Article{"", "March", "", "", "", "2020-03-30T12:00:00Z", nil},
+		Article{"", "April", "", "", "", "2020-04-02T01:30:00Z", nil},
+		Article{"", "Undated", "", "", "", "yesterday", nil},
+	}
+	tables := []struct {
+		from     string
+		to       string
+		expected []string
+	}{
+		{"", "", []string{"March", "April", "Undated"}},
+		{"2020-04-01", "", []string{"April"}},
+		{"", "3/30/20", []string{"March"}},
+		{"2020-03-30", "2020-04-02", []string{"March", "April"}},
+	}
+	for _, table := range tables {
+		result := getTitles(filterArticlesBetweenDates(articles, table.from, table.to))
+		if strings.Join(result, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Articles between %s and %s are incorrect, got: %v, want: %v.", table.from, table.to, result, table.expected)
+		}
+	}
+}
+
+func TestFeedProvider_GetArticles(t *testing.T) {
+	previousClient := client
+	defer func() { client = previousClient }()
+	client = &feedClient{map[string]string{"https://who.example/feed": testAtomFeed, "https://moh.example/rss": testRSSFeed}}
+	provider := newFeedProvider(map[string][]feedSource{
+		"":   []feedSource{{"https://who.example/feed", "en"}},
+		"sg": []feedSource{{"https://moh.example/rss", "en"}, {"https://moh.example/missing", "en"}, {"https://moh.example/rss-zh", "zh"}},
+	})
+	tables := []struct {
+		country  string
+		language string
+		from     string
+		expected []string
+	}{
+		{"", "", "", []string{"WHO statement"}},
+		{"us", "en", "", []string{"WHO statement"}},
+		{"SG", "", "", []string{"WHO statement", "Update on COVID-19", "Older update"}},
+		{"sg", "EN", "2020-04-01", []string{"WHO statement", "Update on COVID-19"}},
+		{"sg", "fr", "", []string{}},
+	}
+	for _, table := range tables {
+		articles, _, err := provider.GetArticles(Query{From: table.from, Country: table.country, Language: table.language})
+		if result := getTitles(articles); err != nil || strings.Join(result, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Articles for %s are incorrect, got: %v %v, want: %v.", table.country, result, err, table.expected)
+		}
+	}
+	if len(provider.feeds[""]) != 1 {
+		t.Errorf("Feeds for every country should not be modified, got: %v.", provider.feeds[""])
+	}
+}
+
+func TestParseFeed_Fixtures(t *testing.T) {
+	tables := []struct {
+		file     string
+		expected []Article
+	}{
+		{"rss.xml", []Article{
+			Article{"World Health Organization", "Coronavirus disease (COVID-19) & travel advice", "Updated recommendations for international traffic in relation to the outbreak.",
+				"https://who.example/news/travel-advice", "https://who.example/images/travel-thumbnail.jpg", "2020-03-29T10:15:00Z", nil},
+			Article{"World Health Organization", "Statement on the second meeting of the Emergency Committee", "The Emergency Committee met on 30 January 2020.",
+				"https://who.example/news/emergency-committee", "https://who.example/images/committee.png", "2020-04-02T16:00:00Z", nil},
+			Article{"World Health Organization", "Press briefing transcript", "Transcript of the media briefing.",
+				"https://who.example/news/briefing-transcript", "https://who.example/images/briefing.jpg", "2020-04-03T16:30:00Z", nil},
+		}},
+		{"atom.xml", []Article{
+			Article{"Ministry of Health", "Updates on COVID-19 local situation", "As of 4 April 2020, 12pm, the Ministry of Health has confirmed 75 more cases.",
+				"https://moh.example/news/local-situation", "https://moh.example/images/local-situation.png", "2020-04-04T04:00:00Z", nil},
+			Article{"Ministry of Health", "Circuit breaker measures", "Elevated safe distancing measures to stem the spread.",
+				"https://moh.example/news/circuit-breaker", "https://moh.example/images/circuit-breaker.jpg", "2020-04-03T11:00:00Z", nil},
+		}},
+		{"latin1.xml", []Article{
+			Article{"Ministère de la Santé", "Point de situation sur le coronavirus", "Mesures prises par le ministère.",
+				"https://sante.example/actualites/point-de-situation", "", "2020-04-04T17:00:00Z", nil},
+		}},
+	}
+	for _, table := range tables {
+		file, err := os.Open(filepath.Join("testdata", table.file))
+		if err != nil {
+			t.Fatalf("Fixture %s could not be opened: %s.", table.file, err.Error())
+		}
+		articles, err := parseFeed(file)
+		file.Close()
+		if err != nil || len(articles) != len(table.expected) {
+			t.Errorf("Articles of %s are incorrect, got: %+v %v, want: %+v.", table.file, articles, err, table.expected)
+			continue
+		}
+		for i, article := range articles {
+			if !reflect.DeepEqual(article, table.expected[i]) {
+				t.Errorf("Article %d of %s is incorrect, got: %+v, want: %+v.", i, table.file, article, table.expected[i])
+			}
+		}
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	tables := []struct {
+		text     string
+		expected string
+	}{
+		{"  Plain   text\n", "Plain text"},
+		{"<p>Cases &amp; deaths</p><p>rose</p>", "Cases & deaths rose"},
+		{"1 &lt; 2", "1 < 2"},
+		{"", ""},
+	}
+	for _, table := range tables {
+		if result := cleanText(table.text); result != table.expected {
+			t.Errorf("Text is incorrect for %q, got: %q, want: %q.", table.text, result, table.expected)
+		}
+	}
+}
+
+func TestLoadCountryFeeds(t *testing.T) {
+	feeds, err := loadCountryFeeds(filepath.Join("testdata", "feeds.json"))
+	expected := map[string][]feedSource{
+		"":   []feedSource{{"https://who.example/rss", "en"}},
+		"sg": []feedSource{{"https://moh.example/atom", "en"}},
+		"fr": []feedSource{{"https://sante.example/rss", "fr"}},
+	}
+	if err != nil || !reflect.DeepEqual(feeds, expected) {
+		t.Errorf("Feeds are incorrect, got: %v %v, want: %v.", feeds, err, expected)
+	}
+	if feeds, err := loadCountryFeeds(filepath.Join("testdata", "missing.json")); err != nil || len(feeds) != 0 {
+		t.Errorf("Feeds should be empty for a missing file, got: %v %v.", feeds, err)
+	}
+	if _, err := loadCountryFeeds(filepath.Join("testdata", "rss.xml")); err == nil {
+		t.Errorf("Error should be returned for a file that is not JSON.")
+	}
+}
+
+func TestFeedProvider_GetArticlesFromFixtures(t *testing.T) {
+	previousClient := client
+	defer func() { client = previousClient }()
+	client = &fixtureClient{map[string]string{"https://who.example/rss": "rss.xml", "https://moh.example/atom": "atom.xml", "https://sante.example/rss": "latin1.xml"}}
+	feeds, err := loadCountryFeeds(filepath.Join("testdata", "feeds.json"))
+	if err != nil {
+		t.Fatalf("Feeds could not be loaded: %s.", err.Error())
+	}
+	provider := newFeedProvider(feeds)
+	tables := []struct {
+		country  string
+		language string
+		from     string
+		to       string
+		expected int
+	}{
+		{"", "", "", "", 3},
+		{"sg", "", "", "", 5},
+		{"fr", "", "2020-04-03", "", 1},
+		{"fr", "fr", "2020-04-03", "", 1},
+		{"sg", "en", "2020-04-02", "2020-04-03", 3},
+	}
+	for _, table := range tables {
+		articles, total, err := provider.GetArticles(Query{From: table.from, To: table.to, Country: table.country, Language: table.language})
+		if err != nil || len(articles) != table.expected || total != table.expected {
+			t.Errorf("Articles for %s in %s between %s and %s are incorrect, got: %v %d %v, want: %d articles.", table.country, table.language, table.from, table.to, getTitles(articles), total, err, table.expected)
+		}
+	}
+}
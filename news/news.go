@@ -1,13 +1,11 @@
 package news
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"os"
-	"strings"
-	"sync"
+	"time"
 
 	"yet-another-covid-map-api/utils"
 )
@@ -22,101 +20,150 @@ type Article struct {
 	URL          string `json:"url"`
 	ThumbnailURL string `json:"thumbnailUrl"`
 	PublishedAt  string `json:"publishedAt"`
+	// Alternates : other sources of the same story with a nearly identical headline, which were published after this one
+	Alternates []Alternate `json:"alternates,omitempty"`
 }
 
-type inputSource struct {
-	ID   string
-	Name string
+// Alternate : another source of the same story as an article
+type Alternate struct {
+	Source      string `json:"source"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	PublishedAt string `json:"publishedAt"`
 }
 
-type inputArticle struct {
-	Source      inputSource
-	Author      string
-	Title       string
-	Description string
-	URL         string
-	URLToImage  string
-	PublishedAt string
-	Content     string
-}
-
-type newsResponse struct {
-	Status       string
-	TotalResults int
-	Articles     []inputArticle
+// GetPublishedDay : the day in UTC that the article was published on, or false if its publication date is not valid
+func (a Article) GetPublishedDay() (time.Time, bool) {
+	publishedAt, ok := parsePublishedAt(a.PublishedAt)
+	if !ok {
+		return time.Time{}, false
+	}
+	return publishedAt.UTC().Truncate(24 * time.Hour), true
 }
 
 // Please populate this field with your own News API key
 var apiKey string
 
-const (
-	newsEnvironmentVar  string = "NEWS_API_KEY"
-	newsAPIHeadlinesURL string = "https://newsapi.org/v2/top-headlines"
-)
+const newsEnvironmentVar string = "NEWS_API_KEY"
+
+// ErrNotConfigured : returned for news queries when there is neither a News API key nor any feeds to get news from
+var ErrNotConfigured = errors.New("News is not available, because neither a News API key nor any news feeds are configured")
 
 func init() {
 	apiKey = os.Getenv(newsEnvironmentVar)
 	if apiKey == "" {
-		log.Fatal("News API key is not populated! Please add your apiKey to your " + newsEnvironmentVar + " environment variable.")
+		log.Println("News API key is not populated, so news will only come from the configured feeds. Please add your apiKey to your " +
+			newsEnvironmentVar + " environment variable to get news from the News API.")
 	}
 	client = &http.Client{}
+	if path := getFeedsFile(); path == "" {
+		log.Println("No news feeds are used, please set the " + feedsEnvironmentVar + " environment variable to the feeds configuration file to use them.")
+	} else if feeds, err := loadCountryFeeds(path); err != nil {
+		log.Printf("Unable to load the news feeds, only the News API will be used: %s\n", err.Error())
+	} else {
+		countryFeeds = feeds
+	}
+	providers = getProviders(apiKey, countryFeeds)
+	if len(providers) == 0 {
+		log.Println(ErrNotConfigured.Error())
+	}
 }
 
-func readJSONFromURL(url string) (newsResponse, error) {
-	log.Printf("calling News API at: %s\n", url)
-	r, err := client.Get(url)
-
-	response := newsResponse{}
-	if err != nil {
-		return response, err
+// getProviders : get the News API provider if there is an API key, and the feed provider if there are feeds
+func getProviders(apiKey string, feeds map[string][]feedSource) []NewsProvider {
+	var configured []NewsProvider
+	if apiKey != "" {
+		configured = append(configured, newsAPIProvider{})
+	}
+	if len(feeds) > 0 {
+		configured = append(configured, newFeedProvider(feeds))
 	}
-	defer r.Body.Close()
+	return configured
+}
 
-	decodeErr := json.NewDecoder(r.Body).Decode(&response)
-	return response, decodeErr
+// IsAvailable : whether there is any provider to get news from
+func IsAvailable() bool {
+	return len(providers) > 0
 }
 
-func formSingleURLQuery(queryName string, value string) string {
-	if value != "" {
-		return fmt.Sprintf("&%s=%s", queryName, value)
+// GetProviderNames : get the names of the providers that news is merged from
+func GetProviderNames() []string {
+	names := make([]string, len(providers))
+	for i, provider := range providers {
+		names[i] = provider.Name()
 	}
-	return ""
+	return names
 }
 
-func formURLQuery(from string, to string, country string) string {
-	return fmt.Sprintf("%s?apiKey=%s&q=virus&language=en%s%s%s", newsAPIHeadlinesURL, apiKey,
-		formSingleURLQuery("from", from), formSingleURLQuery("to", to), formSingleURLQuery("country", country))
+// GetNews : get a page of the coronavirus related headlines for the query from every provider, where page starts from 1 and 0 means the default.
+// The articles of every provider and the archive up to the end of the page are merged before the page is cut from them. Pages are cached, so the providers are only called when they are not cached yet or are stale, in which case the stale page is returned
+// while it is refreshed
+func GetNews(query Query, page int, pageSize int) (Page, error) {
+	if !IsAvailable() {
+		return Page{}, ErrNotConfigured
+	}
+	query.Page, query.PageSize = page, pageSize
+	query = query.normalize()
+	return cache.get(getCacheKey(query), func() (Page, error) {
+		return fetchPage(providers, query)
+	})
 }
 
-func formatArticle(input inputArticle, ch chan Article, wg *sync.WaitGroup) {
-	ch <- Article{input.Source.Name, input.Title, input.Description, input.URL, input.URLToImage, input.PublishedAt}
-	wg.Done()
+// GetAllNews : get the headlines for the query in a single page of MaxPageSize, which is the most that the News API returns for a query
+func GetAllNews(query Query) ([]Article, error) {
+	page, err := GetNews(query, 1, MaxPageSize)
+	if err != nil {
+		return nil, err
+	}
+	return page.Articles, nil
 }
 
-func formatResponse(input []inputArticle) []Article {
-	numRows := len(input)
-	ch := make(chan Article, numRows)
-	wg := sync.WaitGroup{}
-	for index := 0; index < numRows; index++ {
-		wg.Add(1)
-		go formatArticle(input[index], ch, &wg)
-	}
-	wg.Wait()
-	close(ch)
-	var result []Article
-	set := make(map[string]bool)
-	for item := range ch {
-		if _, ok := set[item.Title]; !ok {
-			set[item.Title] = true
-			result = append(result, item)
-		}
+// fetchPage : get the page of the query from the merged articles of the providers and the archive, with near duplicate headlines collapsed.
+// The articles are sorted from the latest to the earliest published unless the News API sorts them in another order. The total also counts
+// the articles that the providers have for the query after the ones that were fetched for the page
+func fetchPage(providers []NewsProvider, query Query) (Page, error) {
+	articles, unfetched, err := getArticlesFromProvidersAndArchive(providers, query)
+	if err != nil {
+		return Page{}, err
+	}
+	articles = collapseNearDuplicates(articles)
+	if query.SortBy == "" || query.SortBy == "publishedAt" {
+		sortByPublishedAt(articles)
 	}
-	return result
+	page := getPage(articles, query.Page, query.PageSize)
+	page.TotalResults += unfetched
+	if page.Page < getPageCount(page.TotalResults, page.PageSize) {
+		page.NextPage = page.Page + 1
+	}
+	return page, nil
 }
 
-// GetNews : get coronavirus related headlines for the country passed in the parameter and return them
-func GetNews(from string, to string, country string) ([]Article, error) {
-	urlQuery := formURLQuery(from, to, strings.ToLower(country))
-	response, err := readJSONFromURL(urlQuery)
-	return formatResponse(response.Articles), err
+// getArticlesFromProvidersAndArchive : get the articles for the query from the providers and archive them, with the number of articles that the
+// providers did not return. If the query starts before the window of the providers, the archived articles are added, and if it also ends before
+// the window it is only answered from the archive. If the providers fail, the archived articles are still returned if there are any
+func getArticlesFromProvidersAndArchive(providers []NewsProvider, query Query) ([]Article, int, error) {
+	if archive == nil {
+		return getArticlesFromProviders(providers, query)
+	}
+	var archived []Article
+	if archive.isBeforeWindow(query.From) {
+		var archiveErr error
+		if archived, archiveErr = archive.get(query); archiveErr != nil {
+			log.Printf("Reading archived news failed: %s\n", archiveErr.Error())
+		}
+		if archive.isBeforeWindow(query.To) {
+			return archived, 0, nil
+		}
+	}
+	articles, unfetched, err := getArticlesFromProviders(providers, query)
+	if err != nil {
+		if len(archived) > 0 {
+			return archived, 0, nil
+		}
+		return nil, 0, err
+	}
+	if err := archive.store(query, articles); err != nil {
+		log.Printf("Archiving news failed: %s\n", err.Error())
+	}
+	return mergeArticles([][]Article{articles, archived}), unfetched, nil
 }
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"sort"
+	"strings"
 	"testing"
 )
 
@@ -43,21 +45,29 @@ func defaultJSONResponse() (*http.Response, error) {
 	}, nil
 }
 
+// setTestNewsAPI : get news only from the News API with a test key, an empty cache and no archive, whether or not NEWS_API_KEY is set
+func setTestNewsAPI() {
+	apiKey = "testkey"
+	providers = []NewsProvider{newsAPIProvider{}}
+	cache = newNewsCache(defaultCacheTTL)
+	archive = nil
+}
+
 func TestFormURLQuery(t *testing.T) {
 	apiKey = "testkey"
 	tables := []struct {
-		from     string
-		to       string
-		country  string
+		query    Query
 		expected string
 	}{
-		{"2020-01-02", "2020-01-03", "", "https://newsapi.org/v2/top-headlines?apiKey=testkey&q=virus&language=en&from=2020-01-02&to=2020-01-03"},
-		{"", "", "sg", "https://newsapi.org/v2/top-headlines?apiKey=testkey&q=virus&language=en&country=sg"},
-		{"2020-01-02", "", "us", "https://newsapi.org/v2/top-headlines?apiKey=testkey&q=virus&language=en&from=2020-01-02&country=us"},
+		{Query{"2020-01-02", "2020-01-03", "", "", "en", "", 1, 100}, "https://newsapi.org/v2/top-headlines?apiKey=testkey&from=2020-01-02&language=en&pageSize=100&q=virus&to=2020-01-03"},
+		{Query{"", "", "sg", "", "en", "", 1, 20}, "https://newsapi.org/v2/top-headlines?apiKey=testkey&country=sg&language=en&pageSize=20&q=virus"},
+		{Query{"2020-01-02", "", "us", "", "en", "", 3, 5}, "https://newsapi.org/v2/top-headlines?apiKey=testkey&country=us&from=2020-01-02&language=en&pageSize=15&q=virus"},
+		{Query{"", "", "", "covid vaccine & trials", "fr", "publishedAt", 2, 50}, "https://newsapi.org/v2/everything?apiKey=testkey&language=fr&pageSize=100&q=covid+vaccine+%26+trials&sortBy=publishedAt"},
+		{Query{"", "", "sg", "", "en", "", 0, 0}, "https://newsapi.org/v2/top-headlines?apiKey=testkey&country=sg&language=en&pageSize=20&q=virus"},
 	}
 
 	for _, table := range tables {
-		result := formURLQuery(table.from, table.to, table.country)
+		result := formURLQuery(table.query)
 		if result != table.expected {
 			t.Errorf("Result of formUrlQuery was incorrect, got: %s, want: %s.", result, table.expected)
 		}
@@ -65,27 +75,53 @@ func TestFormURLQuery(t *testing.T) {
 }
 
 func TestGetNews(t *testing.T) {
+	setTestNewsAPI()
 	client = &mockClient{}
 	mockJSONResponseFn = defaultJSONResponse
-	result, _ := GetNews("", "", "sg")
+	page, _ := GetNews(Query{Country: "SG"}, 0, 0)
+	result := page.Articles
 	expected := []Article{
-		Article{"Google News", "headline", "desc", "testUrl", "imgUrl", "2020-04-02T01:01:22Z"},
-		Article{"Google News2", "headline2", "desc2", "testUrl2", "imgUrl2", "2020-04-02T02:01:22Z"},
+		Article{"Google News", "headline", "desc", "testUrl", "imgUrl", "2020-04-02T01:01:22Z", nil},
+		Article{"Google News2", "headline2", "desc2", "testUrl2", "imgUrl2", "2020-04-02T02:01:22Z", nil},
 	}
 	sort.Sort(ByArticleTitle(result))
 	for i, item := range result {
-		if item != expected[i] {
+		if !reflect.DeepEqual(item, expected[i]) {
 			t.Errorf("Result of GetNews is incorrect, got: %+v, want: %+v.", item, expected[i])
 		}
 	}
 }
 
+func TestGetNews_SameTitle(t *testing.T) {
+	setTestNewsAPI()
+	client = &mockClient{}
+	mockJSONResponseFn = func() (*http.Response, error) {
+		jsonStr := `{"status":"ok","totalResults":2,"articles":[
+			{"source":{"id":"google-news","name":"Google News"},"author":"ST","title":"headline","description":"desc","url":"testUrl","urlToImage":"imgUrl","publishedAt":"2020-04-02T01:01:22Z","content":"testcontent"},
+			{"source":{"id":"google-news2","name":"Google News2"},"author":"ST2","title":"headline","description":"desc2","url":"testUrl2","urlToImage":"imgUrl2","publishedAt":"2020-04-02T02:01:22Z","content":"testcontent2"}
+			]}`
+		r := ioutil.NopCloser(bytes.NewReader([]byte(jsonStr)))
+		return &http.Response{
+			StatusCode: 200,
+			Body:       r,
+		}, nil
+	}
+	page, err := GetNews(Query{Country: "SG"}, 1, DefaultPageSize)
+	expected := []Article{
+		Article{"Google News", "headline", "desc", "testUrl", "imgUrl", "2020-04-02T01:01:22Z", []Alternate{Alternate{"Google News2", "headline", "testUrl2", "2020-04-02T02:01:22Z"}}},
+	}
+	if err != nil || !reflect.DeepEqual(page.Articles, expected) {
+		t.Errorf("Articles with the same title should be collapsed into alternates, got: %+v %v, want: %+v.", page.Articles, err, expected)
+	}
+}
+
 func TestGetNews_ReadJSONFailed(t *testing.T) {
+	setTestNewsAPI()
 	expected := "test failure"
 	mockJSONResponseFn = func() (*http.Response, error) {
 		return nil, errors.New(expected)
 	}
-	_, err := GetNews("", "", "sg")
+	_, err := GetNews(Query{Country: "sg"}, 1, DefaultPageSize)
 	if err == nil {
 		t.Error("GetNews should have thrown an error but it didn't.")
 	}
@@ -95,6 +131,7 @@ func TestGetNews_ReadJSONFailed(t *testing.T) {
 }
 
 func TestGetNews_MalformedJSON(t *testing.T) {
+	setTestNewsAPI()
 	mockJSONResponseFn = func() (*http.Response, error) {
 		jsonStr := `{"status":"ok","totalResults":19,"articles":[]`
 		r := ioutil.NopCloser(bytes.NewReader([]byte(jsonStr)))
@@ -103,7 +140,7 @@ func TestGetNews_MalformedJSON(t *testing.T) {
 			Body:       r,
 		}, nil
 	}
-	_, err := GetNews("", "", "sg")
+	_, err := GetNews(Query{Country: "sg"}, 1, DefaultPageSize)
 	if err == nil {
 		t.Error("GetNews should have thrown an error but it didn't.")
 	}
@@ -116,14 +153,95 @@ func TestFormatResponse(t *testing.T) {
 		inputArticle{inputSource{"google-news2", "Google News2"}, "ST2", "headline2", "desc2", "testUrl2", "imgUrl2", "2020-04-02T02:01:22Z", "testcontent2"},
 	}
 	expected := []Article{
-		Article{"Google News", "headline", "desc", "testUrl", "imgUrl", "2020-04-02T01:01:22Z"},
-		Article{"Google News2", "headline2", "desc2", "testUrl2", "imgUrl2", "2020-04-02T02:01:22Z"},
+		Article{"Google News", "headline", "desc", "testUrl", "imgUrl", "2020-04-02T01:01:22Z", nil},
+		Article{"Google News2", "headline2", "desc2", "testUrl2", "imgUrl2", "2020-04-02T02:01:22Z", nil},
+		Article{"Google News2", "headline2", "desc2", "testUrl2", "imgUrl2", "2020-04-02T02:01:22Z", nil},
 	}
 	result := formatResponse(input)
-	sort.Sort(ByArticleTitle(result))
+	if len(result) != len(expected) {
+		t.Errorf("Length of result of formatResponse is incorrect, got: %d, want: %d.", len(result), len(expected))
+	}
 	for i, item := range result {
-		if item != expected[i] {
+		if !reflect.DeepEqual(item, expected[i]) {
 			t.Errorf("Item in result of formatResponse is different from expected, got: %+v, want: %+v.", item, expected[i])
 		}
 	}
 }
+
+func TestReadJSONFromURL_Errors(t *testing.T) {
+	client = &mockClient{}
+	tables := []struct {
+		statusCode       int
+		body             string
+		expected         APIError
+		rateLimited      bool
+		invalidParameter bool
+	}{
+		{429, `{"status":"error","code":"rateLimited","message":"You have made too many requests recently."}`,
+			APIError{429, "rateLimited", "You have made too many requests recently."}, true, false},
+		{401, `{"status":"error","code":"apiKeyInvalid","message":"Your API key is invalid or incorrect."}`,
+			APIError{401, "apiKeyInvalid", "Your API key is invalid or incorrect."}, false, false},
+		{400, `{"status":"error","code":"parameterInvalid","message":"You've included a parameter in your request which is currently not supported."}`,
+			APIError{400, "parameterInvalid", "You've included a parameter in your request which is currently not supported."}, false, true},
+		{426, `{"status":"error","code":"apiKeyExhausted","message":"Your API key has no more requests available."}`,
+			APIError{426, "apiKeyExhausted", "Your API key has no more requests available."}, true, false},
+		{200, `{"status":"error","code":"unexpectedError","message":"Something went wrong."}`,
+			APIError{200, "unexpectedError", "Something went wrong."}, false, false},
+		{500, `<html>Internal Server Error</html>`, APIError{500, "", "Internal Server Error"}, false, false},
+	}
+	for _, table := range tables {
+		statusCode, body := table.statusCode, table.body
+		mockJSONResponseFn = func() (*http.Response, error) {
+			return &http.Response{StatusCode: statusCode, Body: ioutil.NopCloser(bytes.NewReader([]byte(body)))}, nil
+		}
+		_, err := readJSONFromURL("https://newsapi.org/v2/top-headlines")
+		apiErr, ok := err.(*APIError)
+		if !ok || *apiErr != table.expected {
+			t.Errorf("Error is incorrect for status %d, got: %v, want: %+v.", table.statusCode, err, table.expected)
+			continue
+		}
+		if apiErr.IsRateLimited() != table.rateLimited || apiErr.IsInvalidParameter() != table.invalidParameter {
+			t.Errorf("Kind of error is incorrect for %s, got: rate limited %t invalid parameter %t, want: %t %t.", apiErr.Code, apiErr.IsRateLimited(),
+				apiErr.IsInvalidParameter(), table.rateLimited, table.invalidParameter)
+		}
+	}
+}
+
+func TestGetProviders(t *testing.T) {
+	tables := []struct {
+		apiKey   string
+		feeds    map[string][]feedSource
+		expected []string
+	}{
+		{"key", map[string][]feedSource{"": []feedSource{{"https://who.example/rss", "en"}}}, []string{"News API", "RSS and Atom feeds"}},
+		{"key", map[string][]feedSource{}, []string{"News API"}},
+		{"", map[string][]feedSource{"sg": []feedSource{{"https://moh.example/rss", "en"}}}, []string{"RSS and Atom feeds"}},
+		{"", map[string][]feedSource{}, []string{}},
+	}
+	for _, table := range tables {
+		result := getProviders(table.apiKey, table.feeds)
+		names := make([]string, len(result))
+		for i, provider := range result {
+			names[i] = provider.Name()
+		}
+		if strings.Join(names, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Providers are incorrect for key %q and feeds %v, got: %v, want: %v.", table.apiKey, table.feeds, names, table.expected)
+		}
+	}
+}
+
+func TestGetNews_NotConfigured(t *testing.T) {
+	setTestNewsAPI()
+	previousKey := apiKey
+	defer func() { apiKey = previousKey }()
+	apiKey, providers = "", getProviders("", map[string][]feedSource{})
+	if IsAvailable() || len(GetProviderNames()) != 0 {
+		t.Errorf("News should not be available without providers, got providers: %v.", GetProviderNames())
+	}
+	if _, err := GetNews(Query{Country: "sg"}, 1, DefaultPageSize); err != ErrNotConfigured {
+		t.Errorf("Error should be that news is not configured, got: %v.", err)
+	}
+	if IsSupportedCountry("sg") {
+		t.Error("Countries of the News API should not be supported without an API key.")
+	}
+}
@@ -0,0 +1,159 @@
+package news
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	newsAPIHeadlinesURL string = "https://newsapi.org/v2/top-headlines"
+	// newsAPIEverythingURL : search of the news from every country, which is the only one that can be sorted
+	newsAPIEverythingURL string = "https://newsapi.org/v2/everything"
+	// newsQuery : keywords that the headlines are searched for
+	newsQuery string = "virus"
+)
+
+type inputSource struct {
+	ID   string
+	Name string
+}
+
+type inputArticle struct {
+	Source      inputSource
+	Author      string
+	Title       string
+	Description string
+	URL         string
+	URLToImage  string
+	PublishedAt string
+	Content     string
+}
+
+type newsResponse struct {
+	Status       string
+	TotalResults int
+	Articles     []inputArticle
+	// Code and Message : why the request failed when the status is error
+	Code    string
+	Message string
+}
+
+// APIError : an error response of the News API, with its HTTP status and its error code such as rateLimited or apiKeyInvalid
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("News API failed with status %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("News API failed with %s: %s", e.Code, e.Message)
+}
+
+// IsRateLimited : whether the request was rejected because too many requests were made with the API key
+func (e *APIError) IsRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.Code == "rateLimited" || e.Code == "apiKeyExhausted"
+}
+
+// IsInvalidParameter : whether the request was rejected because of the parameters of the query, rather than because of the API key or the News API
+func (e *APIError) IsInvalidParameter() bool {
+	switch e.Code {
+	case "parameterInvalid", "parametersMissing", "sourcesTooMany", "sourceDoesNotExist", "maximumResultsReached":
+		return true
+	}
+	return false
+}
+
+// newsAPIProvider : headlines from the News API (https://newsapi.org/)
+type newsAPIProvider struct{}
+
+func (p newsAPIProvider) Name() string {
+	return "News API"
+}
+
+// GetArticles : get the headlines for the query from the News API up to the end of its page, or none if it does not have headlines for the country.
+// The number of headlines is its totalResults, but at most MaxPageSize because the News API does not return more
+func (p newsAPIProvider) GetArticles(query Query) ([]Article, int, error) {
+	if query.Country != "" && !newsAPICountries[query.Country] {
+		return nil, 0, nil
+	}
+	response, err := readJSONFromURL(formURLQuery(query))
+	if err != nil {
+		return nil, 0, err
+	}
+	articles := formatResponse(response.Articles)
+	total := response.TotalResults
+	if total > MaxPageSize {
+		total = MaxPageSize
+	}
+	if total < len(articles) {
+		total = len(articles)
+	}
+	return articles, total, nil
+}
+
+// readJSONFromURL : get the response of the News API, or an APIError if it returned an error
+func readJSONFromURL(url string) (newsResponse, error) {
+	log.Printf("calling News API at: %s\n", url)
+	r, err := client.Get(url)
+
+	response := newsResponse{}
+	if err != nil {
+		return response, err
+	}
+	defer r.Body.Close()
+
+	decodeErr := json.NewDecoder(r.Body).Decode(&response)
+	if r.StatusCode != http.StatusOK || response.Status == "error" {
+		if decodeErr != nil || response.Message == "" {
+			response.Message = http.StatusText(r.StatusCode)
+		}
+		return newsResponse{}, &APIError{r.StatusCode, response.Code, response.Message}
+	}
+	return response, decodeErr
+}
+
+func setURLQuery(values url.Values, queryName string, value string) {
+	if value != "" {
+		values.Set(queryName, value)
+	}
+}
+
+// formURLQuery : get the URL of the News API for the headlines of the query up to the end of its page, which are all in the first page of the
+// News API so that the page can be cut from the articles of every provider. Sorted queries search the news from every country, the others get
+// the headlines
+func formURLQuery(query Query) string {
+	values := url.Values{}
+	values.Set("apiKey", apiKey)
+	values.Set("q", query.getKeywords())
+	values.Set("pageSize", strconv.Itoa(query.getFetchSize()))
+	setURLQuery(values, "language", query.Language)
+	setURLQuery(values, "from", query.From)
+	setURLQuery(values, "to", query.To)
+	if query.SortBy != "" {
+		values.Set("sortBy", query.SortBy)
+		return newsAPIEverythingURL + "?" + values.Encode()
+	}
+	setURLQuery(values, "country", query.Country)
+	return newsAPIHeadlinesURL + "?" + values.Encode()
+}
+
+func formatArticle(input inputArticle) Article {
+	return Article{input.Source.Name, input.Title, input.Description, input.URL, input.URLToImage, input.PublishedAt, nil}
+}
+
+// formatResponse : get the articles of the News API in the order it returned them. Articles with the same title are kept, as they are
+// collapsed into alternates together with near-duplicates from every provider
+func formatResponse(input []inputArticle) []Article {
+	result := make([]Article, len(input))
+	for i, item := range input {
+		result[i] = formatArticle(item)
+	}
+	return result
+}
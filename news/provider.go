@@ -0,0 +1,75 @@
+package news
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"yet-another-covid-map-api/utils"
+)
+
+// NewsProvider : a source of coronavirus related news
+type NewsProvider interface {
+	// Name : name of the provider in logs
+	Name() string
+	// GetArticles : get the articles for the query up to the end of its page, whose country is a lower case iso2 and whose dates are in
+	// dateformat.NewsDateFormat, with the number of articles that the provider has for the query, which is more than the articles returned
+	// if it pages them
+	GetArticles(query Query) ([]Article, int, error)
+}
+
+// providers : the providers that news is merged from, in order of preference when the same article comes from more than one of them
+var providers []NewsProvider
+
+// getArticlesFromProviders : get the articles from all the providers at the same time and merge them, with the number of articles that the
+// providers have for the query but did not return. A provider that fails is skipped, unless all of them fail, in which case the error of the
+// first one is returned. If the News API rejects the parameters of the query, that error is returned even if other providers succeed, because
+// the query is not valid
+func getArticlesFromProviders(providers []NewsProvider, query Query) ([]Article, int, error) {
+	results := make([][]Article, len(providers))
+	totals := make([]int, len(providers))
+	errs := make([]error, len(providers))
+	utils.DefaultWorkerPool.Run(len(providers), func(index int) {
+		results[index], totals[index], errs[index] = providers[index].GetArticles(query)
+	})
+	var firstErr error
+	failures := 0
+	unfetched := 0
+	for i, err := range errs {
+		if err == nil {
+			unfetched += totals[i] - len(results[i])
+			continue
+		}
+		log.Printf("Getting news from %s failed: %s\n", providers[i].Name(), err.Error())
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.IsInvalidParameter() {
+			return nil, 0, err
+		}
+		failures++
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if len(providers) > 0 && failures == len(providers) {
+		return nil, 0, firstErr
+	}
+	return mergeArticles(results), unfetched, nil
+}
+
+// mergeArticles : concatenate the articles of each provider in order, dropping the ones with the same URL as an earlier article.
+// Articles with the same headline from other sources are kept, so that they can be listed as alternates
+func mergeArticles(results [][]Article) []Article {
+	var merged []Article
+	seen := make(map[string]bool)
+	for _, articles := range results {
+		for _, article := range articles {
+			url := strings.TrimSpace(article.URL)
+			if url != "" && seen[url] {
+				continue
+			}
+			seen[url] = true
+			merged = append(merged, article)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,83 @@
+package news
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeProvider struct {
+	articles []Article
+	total    int
+	err      error
+}
+
+func (p fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p fakeProvider) GetArticles(query Query) ([]Article, int, error) {
+	if p.total < len(p.articles) {
+		return p.articles, len(p.articles), p.err
+	}
+	return p.articles, p.total, p.err
+}
+
+func getTitles(articles []Article) []string {
+	titles := make([]string, len(articles))
+	for i, article := range articles {
+		titles[i] = article.Title
+	}
+	return titles
+}
+
+func TestGetArticlesFromProviders_MergesAndDedupes(t *testing.T) {
+	testProviders := []NewsProvider{
+		fakeProvider{[]Article{Article{"A", "First", "", "url1", "", "", nil}, Article{"A", "Second", "", "url2", "", "", nil}}, 0, nil},
+		fakeProvider{[]Article{Article{"B", "first ", "", "url3", "", "", nil}, Article{"B", "Other title", "", "url2", "", "", nil}, Article{"B", "Third", "", "url4", "", "", nil}}, 0, nil},
+	}
+	articles, _, err := getArticlesFromProviders(testProviders, Query{Country: "sg"})
+	expected := []string{"First", "Second", "first ", "Third"}
+	if err != nil || len(articles) != len(expected) {
+		t.Fatalf("Merged articles are incorrect, got: %v %v, want: %v.", getTitles(articles), err, expected)
+	}
+	for i, article := range articles {
+		if article.Title != expected[i] {
+			t.Errorf("Article %d is incorrect, got: %s, want: %s.", i, article.Title, expected[i])
+		}
+	}
+	if articles[0].Source != "A" {
+		t.Errorf("Article of the first provider should be kept, got: %s, want: %s.", articles[0].Source, "A")
+	}
+}
+
+func TestGetArticlesFromProviders_SkipsFailedProviders(t *testing.T) {
+	testProviders := []NewsProvider{
+		fakeProvider{nil, 0, errors.New("first failure")},
+		fakeProvider{[]Article{Article{"B", "Headline", "", "url", "", "", nil}}, 0, nil},
+	}
+	articles, _, err := getArticlesFromProviders(testProviders, Query{Country: "sg"})
+	if err != nil || len(articles) != 1 {
+		t.Errorf("Articles of the providers that did not fail should be returned, got: %v %v.", getTitles(articles), err)
+	}
+	testProviders[1] = fakeProvider{nil, 0, errors.New("second failure")}
+	if _, _, err := getArticlesFromProviders(testProviders, Query{Country: "sg"}); err == nil || err.Error() != "first failure" {
+		t.Errorf("Error of the first provider should be returned when all fail, got: %v.", err)
+	}
+	if articles, _, err := getArticlesFromProviders(nil, Query{Country: "sg"}); err != nil || len(articles) != 0 {
+		t.Errorf("No articles should be returned without providers, got: %v %v.", getTitles(articles), err)
+	}
+}
+
+func TestGetArticlesFromProviders_InvalidParameter(t *testing.T) {
+	testProviders := []NewsProvider{
+		fakeProvider{[]Article{Article{"A", "Headline", "", "url", "", "", nil}}, 0, nil},
+		fakeProvider{nil, 0, &APIError{400, "parameterInvalid", "The language is not valid"}},
+	}
+	if _, _, err := getArticlesFromProviders(testProviders, Query{}); err == nil || err.Error() != "News API failed with parameterInvalid: The language is not valid" {
+		t.Errorf("Error about the parameters should be returned even if other providers succeed, got: %v.", err)
+	}
+	testProviders[1] = fakeProvider{nil, 0, &APIError{429, "rateLimited", "Too many requests"}}
+	if articles, _, err := getArticlesFromProviders(testProviders, Query{}); err != nil || len(articles) != 1 {
+		t.Errorf("Articles of the other providers should be returned when rate limited, got: %v %v.", getTitles(articles), err)
+	}
+}
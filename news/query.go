@@ -0,0 +1,143 @@
+package news
+
+import (
+	"strings"
+)
+
+const (
+	// DefaultLanguage : language of the news when it is not given
+	DefaultLanguage = "en"
+	// DefaultPageSize : number of articles in a page when the page size is not given, which is the same as the News API
+	DefaultPageSize = 20
+	// MaxPageSize : largest number of articles in a page, which is also the most articles that the News API returns for a query
+	MaxPageSize = 100
+)
+
+// Languages : the languages that the News API has news in
+var Languages = []string{"ar", "de", "en", "es", "fr", "he", "it", "nl", "no", "pt", "ru", "sv", "ud", "zh"}
+
+// SortOptions : the orders that the News API can sort news from every country in
+var SortOptions = []string{"relevancy", "popularity", "publishedAt"}
+
+// newsAPICountries : the countries that the News API has headlines for by lower case iso2
+var newsAPICountries = map[string]bool{
+	"ae": true, "ar": true, "at": true, "au": true, "be": true, "bg": true, "br": true, "ca": true, "ch": true, "cn": true, "co": true, "cu": true,
+	"cz": true, "de": true, "eg": true, "fr": true, "gb": true, "gr": true, "hk": true, "hu": true, "id": true, "ie": true, "il": true, "in": true,
+	"it": true, "jp": true, "kr": true, "lt": true, "lv": true, "ma": true, "mx": true, "my": true, "ng": true, "nl": true, "no": true, "nz": true,
+	"ph": true, "pl": true, "pt": true, "ro": true, "rs": true, "ru": true, "sa": true, "se": true, "sg": true, "si": true, "sk": true, "th": true,
+	"tr": true, "tw": true, "ua": true, "us": true, "ve": true, "za": true,
+}
+
+// Query : the news that is searched for. Empty fields are not used to filter the news, apart from Keywords and Language which
+// default to coronavirus news in English, and Page and PageSize which default to the first page of DefaultPageSize articles
+type Query struct {
+	From     string
+	To       string
+	Country  string
+	Keywords string
+	Language string
+	SortBy   string
+	Page     int
+	PageSize int
+}
+
+// Page : a page of the news for a query. NextPage is the number of the next page, or 0 if this is the last page
+type Page struct {
+	Articles     []Article `json:"articles"`
+	TotalResults int       `json:"totalResults"`
+	Page         int       `json:"page"`
+	PageSize     int       `json:"pageSize"`
+	NextPage     int       `json:"nextPage,omitempty"`
+}
+
+// IsSupportedCountry : whether there is news for the country with the iso2, because the News API has headlines for it and there is an API key,
+// or because it has its own feeds. Feeds for every country are not enough, because they are not news about the country
+func IsSupportedCountry(country string) bool {
+	country = strings.ToLower(country)
+	return (apiKey != "" && newsAPICountries[country]) || len(countryFeeds[country]) > 0
+}
+
+// getKeywords : the keywords that the News API is searched for
+func (q Query) getKeywords() string {
+	if q.Keywords == "" {
+		return newsQuery
+	}
+	return q.Keywords
+}
+
+// getFetchSize : number of articles that are fetched from a provider that pages them, which is every article up to the end of the page of the query
+// but at most MaxPageSize
+func (q Query) getFetchSize() int {
+	q = q.normalize()
+	if q.Page <= MaxPageSize/q.PageSize {
+		return q.Page * q.PageSize
+	}
+	return MaxPageSize
+}
+
+// normalize : get the query with the country in lower case and the default language and page, so that equal queries have the same cache key
+func (q Query) normalize() Query {
+	q.Country = strings.ToLower(q.Country)
+	q.Keywords = strings.TrimSpace(q.Keywords)
+	if q.Language == "" {
+		q.Language = DefaultLanguage
+	}
+	if q.Page < 1 {
+		q.Page = 1
+	}
+	if q.PageSize < 1 {
+		q.PageSize = DefaultPageSize
+	}
+	return q
+}
+
+// getPage : get the articles of page, which starts from 1, with pageSize articles in each page
+func getPage(articles []Article, page int, pageSize int) Page {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	result := Page{[]Article{}, len(articles), page, pageSize, 0}
+	if page > getPageCount(len(articles), pageSize) {
+		return result
+	}
+	start := (page - 1) * pageSize
+	end := start + pageSize
+	if end < len(articles) {
+		result.NextPage = page + 1
+	} else {
+		end = len(articles)
+	}
+	result.Articles = articles[start:end]
+	return result
+}
+
+// getPageCount : number of pages of pageSize that total articles fill
+func getPageCount(total int, pageSize int) int {
+	return (total + pageSize - 1) / pageSize
+}
+
+// filterArticlesByKeywords : get the articles with every keyword in their title or description, ignoring case
+func filterArticlesByKeywords(articles []Article, keywords string) []Article {
+	terms := strings.Fields(strings.ToLower(keywords))
+	if len(terms) == 0 {
+		return articles
+	}
+	var filtered []Article
+	for _, article := range articles {
+		text := strings.ToLower(article.Title + " " + article.Description)
+		matches := true
+		for _, term := range terms {
+			if !strings.Contains(text, strings.Trim(term, `"+`)) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, article)
+		}
+	}
+	return filtered
+}
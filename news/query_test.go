@@ -0,0 +1,233 @@
+package news
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func getTestArticles(count int) []Article {
+	articles := make([]Article, count)
+	for i := range articles {
+		articles[i] = Article{"", string(rune('a' + i)), "", "", "", "", nil}
+	}
+	return articles
+}
+
+func TestGetPage(t *testing.T) {
+	articles := getTestArticles(5)
+	tables := []struct {
+		page     int
+		pageSize int
+		expected []string
+		nextPage int
+	}{
+		{1, 2, []string{"a", "b"}, 2},
+		{2, 2, []string{"c", "d"}, 3},
+		{3, 2, []string{"e"}, 0},
+		{4, 2, []string{}, 0},
+		{1, 5, []string{"a", "b", "c", "d", "e"}, 0},
+		{0, 0, []string{"a", "b", "c", "d", "e"}, 0},
+	}
+	for _, table := range tables {
+		result := getPage(articles, table.page, table.pageSize)
+		if titles := getTitles(result.Articles); strings.Join(titles, ",") != strings.Join(table.expected, ",") || result.NextPage != table.nextPage || result.TotalResults != 5 {
+			t.Errorf("Page %d of size %d is incorrect, got: %v next %d total %d, want: %v next %d total %d.", table.page, table.pageSize, titles, result.NextPage,
+				result.TotalResults, table.expected, table.nextPage, 5)
+		}
+	}
+	if result := getPage(nil, 1, 2); result.Articles == nil || result.TotalResults != 0 {
+		t.Errorf("Page of no articles should have an empty list of articles, got: %+v.", result)
+	}
+}
+
+func TestIsSupportedCountry(t *testing.T) {
+	setTestNewsAPI()
+	previousFeeds := countryFeeds
+	defer func() { countryFeeds = previousFeeds }()
+	previousKey := apiKey
+	defer func() { apiKey = previousKey }()
+	tables := []struct {
+		apiKey   string
+		feeds    map[string][]feedSource
+		country  string
+		expected bool
+	}{
+		{"testkey", map[string][]feedSource{"vn": []feedSource{{"https://moh.example/rss", "en"}}}, "SG", true},
+		{"testkey", map[string][]feedSource{"vn": []feedSource{{"https://moh.example/rss", "en"}}}, "us", true},
+		{"testkey", map[string][]feedSource{"vn": []feedSource{{"https://moh.example/rss", "en"}}}, "VN", true},
+		{"testkey", map[string][]feedSource{"vn": []feedSource{{"https://moh.example/rss", "en"}}}, "KH", false},
+		{"", map[string][]feedSource{"vn": []feedSource{{"https://moh.example/rss", "en"}}}, "SG", false},
+		{"", map[string][]feedSource{"": []feedSource{{"https://who.example/rss", "en"}}}, "KH", false},
+		{"", map[string][]feedSource{"": []feedSource{{"https://who.example/rss", "en"}}, "kh": []feedSource{{"https://moh.example/rss", "en"}}}, "KH", true},
+		{"testkey", map[string][]feedSource{"": []feedSource{{"https://who.example/rss", "en"}}}, "KH", false},
+	}
+	for _, table := range tables {
+		apiKey, countryFeeds = table.apiKey, table.feeds
+		if result := IsSupportedCountry(table.country); result != table.expected {
+			t.Errorf("Support of %s with feeds %v is incorrect, got: %t, want: %t.", table.country, table.feeds, result, table.expected)
+		}
+	}
+}
+
+func TestFilterArticlesByKeywords(t *testing.T) {
+	articles := []Article{
+		Article{"", "Vaccine trial begins", "Phase 1 of the COVID-19 vaccine", "", "", "", nil},
+		Article{"", "Lockdown extended", "Schools stay closed", "", "", "", nil},
+		Article{"", "COVID-19 testing", "More testing sites", "", "", "", nil},
+	}
+	tables := []struct {
+		keywords string
+		expected []string
+	}{
+		{"", []string{"Vaccine trial begins", "Lockdown extended", "COVID-19 testing"}},
+		{"covid-19", []string{"Vaccine trial begins", "COVID-19 testing"}},
+		{"covid-19 +vaccine", []string{"Vaccine trial begins"}},
+		{`"schools"`, []string{"Lockdown extended"}},
+		{"measles", []string{}},
+	}
+	for _, table := range tables {
+		result := getTitles(filterArticlesByKeywords(articles, table.keywords))
+		if strings.Join(result, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Articles with %s are incorrect, got: %v, want: %v.", table.keywords, result, table.expected)
+		}
+	}
+}
+
+func TestNewsAPIProvider_UnsupportedCountry(t *testing.T) {
+	previousClient := client
+	defer func() { client = previousClient }()
+	called := false
+	client = &mockClient{}
+	mockJSONResponseFn = func() (*http.Response, error) {
+		called = true
+		return defaultJSONResponse()
+	}
+	articles, _, err := newsAPIProvider{}.GetArticles(Query{Country: "kh"})
+	if err != nil || len(articles) != 0 || called {
+		t.Errorf("News API should not be called for a country it has no headlines for, got: %v %v called %t.", articles, err, called)
+	}
+}
+
+// pagingClient : a client that records the URLs it is called with and responds with the first pageSize of totalResults articles, from the
+// latest to the earliest published
+type pagingClient struct {
+	urls         []string
+	totalResults int
+}
+
+func (c *pagingClient) Get(rawurl string) (*http.Response, error) {
+	c.urls = append(c.urls, rawurl)
+	parsed, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	pageSize, _ := strconv.Atoi(parsed.Query().Get("pageSize"))
+	items := make([]string, 0, pageSize)
+	for i := 1; i <= pageSize && i <= c.totalResults; i++ {
+		items = append(items, fmt.Sprintf(`{"source":{"name":"Google News"},"title":"headline %d","url":"testUrl%d","publishedAt":"2020-04-02T%02d:00:00Z"}`, i, i, 23-i%24))
+	}
+	jsonStr := fmt.Sprintf(`{"status":"ok","totalResults":%d,"articles":[%s]}`, c.totalResults, strings.Join(items, ","))
+	return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader([]byte(jsonStr)))}, nil
+}
+
+func TestNewsAPIProvider_GetArticles(t *testing.T) {
+	previousClient := client
+	defer func() { client = previousClient }()
+	apiKey = "testkey"
+	tables := []struct {
+		page         int
+		pageSize     int
+		totalResults int
+		fetchSize    int
+		articles     int
+		total        int
+	}{
+		{1, 20, 45, 20, 20, 45},
+		{2, 20, 45, 40, 40, 45},
+		{3, 20, 45, 60, 45, 45},
+		{4, 25, 500, 100, 100, 100},
+		{3, 50, 500, 100, 100, 100},
+	}
+	for _, table := range tables {
+		testClient := &pagingClient{totalResults: table.totalResults}
+		client = testClient
+		articles, total, err := newsAPIProvider{}.GetArticles(Query{Country: "sg", Language: "en", Page: table.page, PageSize: table.pageSize})
+		if err != nil || len(articles) != table.articles || total != table.total {
+			t.Errorf("Articles of page %d of size %d are incorrect, got: %d of %d %v, want: %d of %d.", table.page, table.pageSize, len(articles), total, err,
+				table.articles, table.total)
+		}
+		expected := fmt.Sprintf("&pageSize=%d&", table.fetchSize)
+		if len(testClient.urls) != 1 || !strings.Contains(testClient.urls[0], expected) {
+			t.Errorf("Every article up to the end of the page should be asked from the News API, got: %v, want: %s.", testClient.urls, expected)
+		}
+	}
+}
+
+func TestFetchPage_MergesBeforePaging(t *testing.T) {
+	previousArchive := archive
+	defer func() { archive = previousArchive }()
+	archive = nil
+	testProviders := []NewsProvider{
+		fakeProvider{[]Article{
+			Article{"A", "Story 1", "", "url1", "", "2020-04-06T00:00:00Z", nil},
+			Article{"A", "Story 3", "", "url3", "", "2020-04-04T00:00:00Z", nil},
+			Article{"A", "Story 5", "", "url5", "", "2020-04-02T00:00:00Z", nil},
+		}, 4, nil},
+		fakeProvider{[]Article{
+			Article{"B", "Story 2", "", "url2", "", "2020-04-05T00:00:00Z", nil},
+			Article{"B", "Story 4", "", "url4", "", "2020-04-03T00:00:00Z", nil},
+			Article{"B", "Story 1", "", "url1", "", "2020-04-06T00:00:00Z", nil},
+		}, 0, nil},
+	}
+	tables := []struct {
+		page     int
+		pageSize int
+		expected []string
+		nextPage int
+	}{
+		{1, 2, []string{"url1", "url2"}, 2},
+		{2, 2, []string{"url3", "url4"}, 3},
+		{3, 2, []string{"url5"}, 0},
+		{1, 5, []string{"url1", "url2", "url3", "url4", "url5"}, 2},
+		{4, 2, []string{}, 0},
+	}
+	for _, table := range tables {
+		page, err := fetchPage(testProviders, Query{Page: table.page, PageSize: table.pageSize})
+		urls := make([]string, len(page.Articles))
+		for i, article := range page.Articles {
+			urls[i] = article.URL
+		}
+		if err != nil || strings.Join(urls, ",") != strings.Join(table.expected, ",") || page.NextPage != table.nextPage || page.TotalResults != 6 {
+			t.Errorf("Page %d of size %d is incorrect, got: %v next %d total %d %v, want: %v next %d total %d.", table.page, table.pageSize, urls, page.NextPage,
+				page.TotalResults, err, table.expected, table.nextPage, 6)
+		}
+	}
+}
+
+func TestGetNews_Pages(t *testing.T) {
+	previousClient := client
+	defer func() { client = previousClient }()
+	setTestNewsAPI()
+	testClient := &pagingClient{totalResults: 3}
+	client = testClient
+	first, err := GetNews(Query{Country: "sg"}, 1, 1)
+	if err != nil || len(first.Articles) != 1 || first.TotalResults != 3 || first.NextPage != 2 {
+		t.Errorf("First page is incorrect, got: %+v %v.", first, err)
+	}
+	second, err := GetNews(Query{Country: "SG", Language: "en"}, 2, 1)
+	if err != nil || len(second.Articles) != 1 || second.NextPage != 3 || second.Articles[0].Title != "headline 2" {
+		t.Errorf("Second page should continue the first page, got: %+v %v.", second, err)
+	}
+	if again, err := GetNews(Query{Country: "sg"}, 1, 1); err != nil || again.Articles[0].Title != first.Articles[0].Title {
+		t.Errorf("First page should be cached, got: %+v %v.", again, err)
+	}
+	if len(testClient.urls) != 2 {
+		t.Errorf("Each page should be fetched once, got: %d calls, want: %d calls.", len(testClient.urls), 2)
+	}
+}
@@ -0,0 +1,93 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	// codeInvalidParameter : a query parameter could not be parsed or is not allowed together with the other parameters
+	codeInvalidParameter = "invalid_parameter"
+	// codeCountryNotFound : the country in the query does not match any known country
+	codeCountryNotFound = "country_not_found"
+	// codeStateNotFound : the state or FIPS code in the query does not match any known state
+	codeStateNotFound = "state_not_found"
+	// codeUpstreamError : a service that the API relies on, such as the news API, failed
+	codeUpstreamError = "upstream_error"
+	// codeRateLimited : a service that the API relies on, such as the news API, has been called too often
+	codeRateLimited = "rate_limited"
+	// codeDataNotLoaded : the case counts have not been loaded since the server started
+	codeDataNotLoaded = "data_not_loaded"
+	// codeNewsUnavailable : there is neither a News API key nor any news feeds configured
+	codeNewsUnavailable = "news_unavailable"
+	// codeInternalError : the response could not be formed
+	codeInternalError = "internal_error"
+)
+
+// suggestion : a value that the client may have meant for the parameter of an error, with a score from 0 to 1 where 1 is an exact match
+type suggestion struct {
+	Value string  `json:"value"`
+	Name  string  `json:"name,omitempty"`
+	Score float64 `json:"score"`
+}
+
+// apiError : error that is returned to the client as a JSON body together with the HTTP status it maps to
+type apiError struct {
+	status      int
+	Code        string       `json:"code"`
+	Message     string       `json:"message"`
+	Parameter   string       `json:"parameter,omitempty"`
+	Suggestions []suggestion `json:"suggestions,omitempty"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func newInvalidParameterError(parameter string, err error) *apiError {
+	return &apiError{http.StatusBadRequest, codeInvalidParameter, err.Error(), parameter, nil}
+}
+
+func newCountryNotFoundError(message string, suggestions []suggestion) *apiError {
+	return &apiError{http.StatusNotFound, codeCountryNotFound, message, "country", suggestions}
+}
+
+func newStateNotFoundError(parameter string, message string, suggestions []suggestion) *apiError {
+	return &apiError{http.StatusNotFound, codeStateNotFound, message, parameter, suggestions}
+}
+
+func newUpstreamError(err error) *apiError {
+	return &apiError{http.StatusBadGateway, codeUpstreamError, err.Error(), "", nil}
+}
+
+func newRateLimitedError(message string) *apiError {
+	return &apiError{http.StatusTooManyRequests, codeRateLimited, message, "", nil}
+}
+
+func newDataNotLoadedError(message string) *apiError {
+	return &apiError{http.StatusServiceUnavailable, codeDataNotLoaded, message, "", nil}
+}
+
+func newNewsUnavailableError(message string) *apiError {
+	return &apiError{http.StatusServiceUnavailable, codeNewsUnavailable, message, "", nil}
+}
+
+// toAPIError : use err as it is if it is already an apiError, otherwise treat it as an internal error
+func toAPIError(err error) *apiError {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	return &apiError{http.StatusInternalServerError, codeInternalError, err.Error(), "", nil}
+}
+
+// writeError : write err as a JSON error response with the status that it maps to
+func writeError(w writer, err error) {
+	apiErr := toAPIError(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(apiErr.status)
+	response, _ := json.Marshal(apiErr)
+	w.Write(response)
+}
@@ -0,0 +1,143 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"yet-another-covid-map-api/news"
+)
+
+func TestWriteError(t *testing.T) {
+	tables := []struct {
+		err            error
+		expectedStatus int
+		expected       apiError
+	}{
+		{
+			newInvalidParameterError("from", errors.New("bad date")),
+			http.StatusBadRequest,
+			apiError{Code: codeInvalidParameter, Message: "bad date", Parameter: "from"},
+		},
+		{
+			newCountryNotFoundError("Country Sngapore not found, did you mean: Singapore?", []suggestion{suggestion{"SG", "Singapore", 0.889}}),
+			http.StatusNotFound,
+			apiError{Code: codeCountryNotFound, Message: "Country Sngapore not found, did you mean: Singapore?", Parameter: "country", Suggestions: []suggestion{suggestion{"SG", "Singapore", 0.889}}},
+		},
+		{
+			newUpstreamError(errors.New("timeout")),
+			http.StatusBadGateway,
+			apiError{Code: codeUpstreamError, Message: "timeout"},
+		},
+		{
+			newRateLimitedError("too many requests"),
+			http.StatusTooManyRequests,
+			apiError{Code: codeRateLimited, Message: "too many requests"},
+		},
+		{
+			newDataNotLoadedError("not loaded"),
+			http.StatusServiceUnavailable,
+			apiError{Code: codeDataNotLoaded, Message: "not loaded"},
+		},
+		{
+			errors.New("json: unsupported value"),
+			http.StatusInternalServerError,
+			apiError{Code: codeInternalError, Message: "json: unsupported value"},
+		},
+	}
+
+	for _, table := range tables {
+		fakeResponse = []byte("")
+		fakeStatusCode = 0
+		writeError(&fakeWriter{}, table.err)
+		if fakeStatusCode != table.expectedStatus {
+			t.Errorf("Status code was incorrect for %s, got: %d, want: %d.", table.err.Error(), fakeStatusCode, table.expectedStatus)
+		}
+		var result apiError
+		if err := json.Unmarshal(fakeResponse, &result); err != nil {
+			t.Errorf("Response should be a JSON error, got: %s.", fakeResponse)
+			continue
+		}
+		if result.Code != table.expected.Code || result.Message != table.expected.Message || result.Parameter != table.expected.Parameter {
+			t.Errorf("Response was incorrect, got: %+v, want: %+v.", result, table.expected)
+		}
+		if len(result.Suggestions) != len(table.expected.Suggestions) {
+			t.Errorf("Suggestions were incorrect, got: %v, want: %v.", result.Suggestions, table.expected.Suggestions)
+			continue
+		}
+		for i, item := range result.Suggestions {
+			if item != table.expected.Suggestions[i] {
+				t.Errorf("Suggestion was incorrect, got: %+v, want: %+v.", item, table.expected.Suggestions[i])
+			}
+		}
+	}
+}
+
+func TestGetResponse_shouldMapDataErrorsToStatus(t *testing.T) {
+	tables := []struct {
+		err            error
+		expectedStatus int
+	}{
+		{newUpstreamError(errors.New("news API is down")), http.StatusBadGateway},
+		{newDataNotLoadedError("not loaded"), http.StatusServiceUnavailable},
+		{errors.New("unexpected"), http.StatusInternalServerError},
+		{toNewsError(&news.APIError{StatusCode: 429, Code: "rateLimited", Message: "You have made too many requests recently."}), http.StatusTooManyRequests},
+		{toNewsError(&news.APIError{StatusCode: 400, Code: "parameterInvalid", Message: "The q parameter is too long."}), http.StatusBadRequest},
+		{toNewsError(&news.APIError{StatusCode: 401, Code: "apiKeyInvalid", Message: "Your API key is invalid or incorrect."}), http.StatusBadGateway},
+		{toNewsError(errors.New("feed timed out")), http.StatusBadGateway},
+		{toNewsError(news.ErrNotConfigured), http.StatusServiceUnavailable},
+	}
+	inputURL, _ := url.Parse("http://localhost:8080/cases")
+	for _, table := range tables {
+		fakeStatusCode = 0
+		getResponse(func(queryParams) ([]byte, error, error) {
+			return nil, nil, table.err
+		}, &fakeWriter{}, inputURL, casesEndpoint)
+		if fakeStatusCode != table.expectedStatus {
+			t.Errorf("Status code was incorrect for %s, got: %d, want: %d.", table.err.Error(), fakeStatusCode, table.expectedStatus)
+		}
+	}
+}
+
+func TestParseURL_ErrorParameter(t *testing.T) {
+	tables := []struct {
+		rawurl    string
+		parameter string
+	}{
+		{"http://localhost:8080/cases?from=1/32/20", "from"},
+		{"http://localhost:8080/cases?to=1/32/20", "to"},
+		{"http://localhost:8080/cases?interval=fortnight", "interval"},
+		{"http://localhost:8080/cases?last=-1", "last"},
+		{"http://localhost:8080/cases?dateFormat=ymd", "dateFormat"},
+	}
+	for _, table := range tables {
+		inputURL, _ := url.Parse(table.rawurl)
+		_, err := parseURL(inputURL, casesEndpoint)
+		apiErr := toAPIError(err)
+		if apiErr.status != http.StatusBadRequest || apiErr.Parameter != table.parameter {
+			t.Errorf("Error was incorrect for %s, got: %d %s, want: %d %s.", table.rawurl, apiErr.status, apiErr.Parameter, http.StatusBadRequest, table.parameter)
+		}
+	}
+}
+
+func TestToNewsError(t *testing.T) {
+	tables := []struct {
+		err      error
+		expected apiError
+	}{
+		{&news.APIError{StatusCode: 429, Code: "rateLimited", Message: "You have made too many requests recently."},
+			apiError{http.StatusTooManyRequests, codeRateLimited, "The News API has been called too often, please try again later: You have made too many requests recently.", "", nil}},
+		{fmt.Errorf("refreshing: %w", &news.APIError{StatusCode: 400, Code: "parameterInvalid", Message: "The q parameter is too long."}),
+			apiError{http.StatusBadRequest, codeInvalidParameter, "The News API rejected the query: The q parameter is too long.", "", nil}},
+		{&news.APIError{StatusCode: 401, Code: "apiKeyInvalid", Message: "Your API key is invalid or incorrect."},
+			apiError{http.StatusBadGateway, codeUpstreamError, "News API failed with apiKeyInvalid: Your API key is invalid or incorrect.", "", nil}},
+	}
+	for _, table := range tables {
+		result := toAPIError(toNewsError(table.err))
+		if result.status != table.expected.status || result.Code != table.expected.Code || result.Message != table.expected.Message {
+			t.Errorf("Error is incorrect for %s, got: %+v, want: %+v.", table.err.Error(), *result, table.expected)
+		}
+	}
+}
@@ -9,41 +9,287 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 	"yet-another-covid-map-api/casecount"
 	"yet-another-covid-map-api/dateformat"
 	"yet-another-covid-map-api/news"
 	"yet-another-covid-map-api/utils"
 )
 
+const (
+	// maxCountrySuggestions : number of similar countries suggested when a country is not found
+	maxCountrySuggestions = 5
+	// maxStateSuggestions : number of similar states suggested when a state is not found
+	maxStateSuggestions = 5
+	// defaultAutocompleteLimit : number of autocomplete matches returned when the limit is not given
+	defaultAutocompleteLimit = 10
+	// maxAutocompleteLimit : largest number of autocomplete matches that can be requested
+	maxAutocompleteLimit = 50
+)
+
 type writer interface {
 	Header() http.Header
 	Write([]byte) (int, error)
 	WriteHeader(statusCode int)
 }
 
-func parseURL(URL *url.URL, dateFormat string) (string, string, string, bool, bool, bool, error) {
-	from := parseURLQuery(URL, "from")
-	to := parseURLQuery(URL, "to")
-	country := parseURLQuery(URL, "country")
+// queryParams : parsed and validated query of a request
+type queryParams struct {
+	from               string
+	to                 string
+	country            string
+	states             []string
+	aggregateCountries bool
+	perDay             bool
+	worldTotal         bool
+	interval           string
+	dateFormat         string
+	query              string
+	limit              int
+	language           string
+	sortBy             string
+	page               int
+	pageSize           int
+}
+
+// getLastDate : latest date that relative dates are resolved against, replaced in tests
+var getLastDate = casecount.GetLastDate
+
+// getToday : current date in UTC that relative news dates are resolved against, replaced in tests
+var getToday = func() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// isNewsAvailable and getNewsProviders : whether news is configured and where it comes from, replaced in tests
+var isNewsAvailable = news.IsAvailable
+var getNewsProviders = news.GetProviderNames
+
+// statusResponse : what the API is able to answer, so that clients can hide features that are not available
+type statusResponse struct {
+	CaseCounts caseCountsStatus `json:"caseCounts"`
+	News       newsStatus       `json:"news"`
+}
+
+// caseCountsStatus : whether the case counts have been loaded and the latest date that there are case counts for
+type caseCountsStatus struct {
+	Loaded   bool   `json:"loaded"`
+	LastDate string `json:"lastDate,omitempty"`
+}
 
-	from, fromOk := dateformat.FormatDate(dateFormat, from)
-	to, toOk := dateformat.FormatDate(dateFormat, to)
-	if !fromOk || !toOk {
-		return "", "", "", false, false, false, errors.New("Date format is not recognised, please use either YYYY-MM-DD, YYYY/MM/DD, MM-DD-YY or MM/DD/YY")
+// newsStatus : whether news is available and the providers that it comes from
+type newsStatus struct {
+	Available bool     `json:"available"`
+	Providers []string `json:"providers"`
+}
+
+// parseURL : parse and validate the parameters of the query that the endpoint accepts, formatting dates in the date layout of the endpoint
+func parseURL(URL *url.URL, e endpoint) (queryParams, error) {
+	values, err := e.parseValues(URL)
+	if err != nil {
+		return queryParams{}, err
+	}
+	from := values.get("from")
+	to := values.get("to")
+	country := values.get("country")
+	states := values.getList("state")
+	fips := values.get("fips")
+	inputDateFormat := values.get("inputDateFormat")
+
+	if last := values.getInt("last"); last > 0 {
+		if from != "" {
+			return queryParams{}, newInvalidParameterError("last", errors.New("Only one of from and last can be given"))
+		}
+		// last counts back from to if it is given, and the resolved dates are already in the layout of the endpoint, so they are not parsed
+		// again in the input date format
+		var end time.Time
+		if to == "" {
+			end, err = e.getLatestDate()
+		} else {
+			end, err = e.parseQueryDate(inputDateFormat, "to", to)
+		}
+		if err != nil {
+			return queryParams{}, err
+		}
+		from = dateformat.ResolveLastDays(e.dateLayout, last, end)
+		to = end.Format(e.dateLayout)
+	} else {
+		if from, err = e.formatQueryDate(inputDateFormat, "from", from); err != nil {
+			return queryParams{}, err
+		}
+		if to, err = e.formatQueryDate(inputDateFormat, "to", to); err != nil {
+			return queryParams{}, err
+		}
 	}
 
 	if country != "" {
 		if countryFromAbbr, ok := utils.GetAbbreviationFromCountry(country); ok {
 			country = countryFromAbbr
 		} else {
-			return "", "", "", false, false, false, fmt.Errorf("Country %s not found, did you mean: %s?", country, countryFromAbbr)
+			return queryParams{}, getCountryNotFoundError(country)
 		}
 	}
-	aggregateCountries := isStringTrue(parseURLQuery(URL, "aggregatecountries"))
-	perDay := isStringTrue(parseURLQuery(URL, "perday"))
-	worldTotal := isStringTrue(parseURLQuery(URL, "worldtotal"))
+	if fips != "" {
+		if len(states) > 0 {
+			return queryParams{}, newInvalidParameterError("fips", errors.New("Only one of state and fips can be given"))
+		}
+		location, err := getStateFromFIPS(fips, country)
+		if err != nil {
+			return queryParams{}, err
+		}
+		country, states = location.ISO2, []string{location.State}
+	} else if len(states) > 0 {
+		if country, states, err = resolveStates(country, states); err != nil {
+			return queryParams{}, err
+		}
+	}
+
+	return queryParams{from, to, country, states, values.getBool("aggregateCountries"), values.getBool("perDay"), values.getBool("worldTotal"),
+		values.get("interval"), values.get("dateFormat"), strings.TrimSpace(values.get("q")), values.getInt("limit"), values.get("language"),
+		values.get("sortBy"), values.getInt("page"), values.getInt("pageSize")}, nil
+}
+
+// getCountryNotFoundError : error for a country that is not known, suggesting the most similar known countries
+func getCountryNotFoundError(country string) error {
+	countrySuggestions := utils.Locations.SuggestCountries(country, maxCountrySuggestions)
+	if len(countrySuggestions) == 0 {
+		return newCountryNotFoundError(fmt.Sprintf("Country %s not found", country), nil)
+	}
+	suggestions := make([]suggestion, len(countrySuggestions))
+	for i, countrySuggestion := range countrySuggestions {
+		suggestions[i] = suggestion{countrySuggestion.ISO, countrySuggestion.Country, countrySuggestion.Score}
+	}
+	return newCountryNotFoundError(fmt.Sprintf("Country %s not found, did you mean: %s?", country, countrySuggestions[0].Country), suggestions)
+}
 
-	return from, to, country, aggregateCountries, perDay, worldTotal, nil
+// resolveStates : get the names of the states identified by a name, FIPS code or UID, all of which must be in the same country.
+// If country is empty, it is the country of the states, as long as each state is only in one country
+func resolveStates(country string, states []string) (string, []string, error) {
+	givenCountry := country
+	resolved := make([]string, 0, len(states))
+	seen := make(map[string]bool, len(states))
+	for _, state := range states {
+		location, err := resolveState(givenCountry, state)
+		if err != nil {
+			return "", nil, err
+		}
+		iso, _ := utils.Locations.GetCountryISO(location)
+		if country == "" {
+			country = iso
+		} else if country != iso {
+			return "", nil, newInvalidParameterError("state", fmt.Errorf("State %s is not in %s, all states must be in the same country", state, country))
+		}
+		if !seen[location.State] {
+			seen[location.State] = true
+			resolved = append(resolved, location.State)
+		}
+	}
+	return country, resolved, nil
+}
+
+// resolveState : get the state identified by a name, FIPS code or UID in country, or in any country if country is empty
+func resolveState(country string, state string) (utils.Location, error) {
+	if country != "" {
+		if location, ok := utils.Locations.GetState(country, state); ok {
+			return location, nil
+		}
+		return utils.Location{}, getStateNotFoundError(country, state)
+	}
+	locations := utils.Locations.FindStates(state)
+	if len(locations) == 1 {
+		return locations[0], nil
+	}
+	if len(locations) == 0 {
+		return utils.Location{}, getStateNotFoundError(country, state)
+	}
+	suggestions := make([]suggestion, len(locations))
+	countries := make([]string, len(locations))
+	for i, location := range locations {
+		iso, _ := utils.Locations.GetCountryISO(location)
+		suggestions[i] = suggestion{iso, location.Country, 1}
+		countries[i] = location.Country
+	}
+	return utils.Location{}, &apiError{http.StatusBadRequest, codeInvalidParameter,
+		fmt.Sprintf("State %s is in more than one country: %s, please give the country", state, strings.Join(countries, ", ")), "country", suggestions}
+}
+
+// getStateNotFoundError : error for a state that is not known, suggesting the most similar known states in country, or in every country if it is empty
+func getStateNotFoundError(country string, state string) error {
+	message := fmt.Sprintf("State %s not found", state)
+	if country != "" {
+		message = fmt.Sprintf("State %s not found in %s", state, country)
+	}
+	stateSuggestions := utils.Locations.SuggestStates(country, state, maxStateSuggestions)
+	if len(stateSuggestions) == 0 {
+		return newStateNotFoundError("state", message, nil)
+	}
+	suggestions := make([]suggestion, len(stateSuggestions))
+	for i, stateSuggestion := range stateSuggestions {
+		suggestions[i] = suggestion{stateSuggestion.State, stateSuggestion.State + ", " + stateSuggestion.Country, stateSuggestion.Score}
+	}
+	return newStateNotFoundError("state", fmt.Sprintf("%s, did you mean: %s?", message, stateSuggestions[0].State), suggestions)
+}
+
+// getStateFromFIPS : get the state with the FIPS code, which must be in country if it is not empty. The iso2 of the returned location is the country of the state
+func getStateFromFIPS(fips string, country string) (utils.Location, error) {
+	location, ok := utils.Locations.GetByFIPS(fips)
+	if !ok {
+		return utils.Location{}, newStateNotFoundError("fips", fmt.Sprintf("FIPS %s not found", fips), nil)
+	}
+	if location.County != "" {
+		return utils.Location{}, newInvalidParameterError("fips", fmt.Errorf("FIPS %s is %s, %s but case counts are only available for states", fips, location.County, location.State))
+	}
+	if location.State == "" {
+		return utils.Location{}, newInvalidParameterError("fips", fmt.Errorf("FIPS %s is not a state", fips))
+	}
+	iso, _ := utils.Locations.GetCountryISO(location)
+	if country != "" && country != iso {
+		return utils.Location{}, newInvalidParameterError("fips", fmt.Errorf("FIPS %s is %s, which is not in %s", fips, location.State, country))
+	}
+	location.ISO2 = iso
+	return location, nil
+}
+
+// formatQueryDate : format an absolute date in inputDateFormat, or a date relative to the latest date such as latest, latest-1w or -14d, into
+// the date layout of the endpoint
+func (e endpoint) formatQueryDate(inputDateFormat string, parameter string, date string) (string, error) {
+	if date == "" {
+		return "", nil
+	}
+	day, err := e.parseQueryDate(inputDateFormat, parameter, date)
+	if err != nil {
+		return "", err
+	}
+	return day.Format(e.dateLayout), nil
+}
+
+// parseQueryDate : parse an absolute date in inputDateFormat, or a date relative to the latest date of the endpoint
+func (e endpoint) parseQueryDate(inputDateFormat string, parameter string, date string) (time.Time, error) {
+	if dateformat.IsRelativeDate(date) {
+		latest, err := e.getLatestDate()
+		if err != nil {
+			return time.Time{}, err
+		}
+		if day, ok := dateformat.ResolveRelativeDay(date, latest); ok {
+			return day, nil
+		}
+	}
+	day, err := dateformat.ParseDate(inputDateFormat, date)
+	if err != nil {
+		return time.Time{}, newInvalidParameterError(parameter, err)
+	}
+	return day, nil
+}
+
+// getLatestDate : today in UTC if the endpoint counts from today, otherwise the last date with case counts
+func (e endpoint) getLatestDate() (time.Time, error) {
+	if e.datesFromToday {
+		return getToday(), nil
+	}
+	latest, ok := getLastDate()
+	if !ok {
+		return time.Time{}, newDataNotLoadedError("Relative dates cannot be used until the case counts have been loaded, please try again later")
+	}
+	return latest, nil
 }
 
 func isStringTrue(str string) bool {
@@ -53,6 +299,40 @@ func isStringTrue(str string) bool {
 	return false
 }
 
+// timelineResponse : case counts of a country for each day, together with the news that was published on that day. If the news could not
+// be fetched, the days have no articles and NewsError says why
+type timelineResponse struct {
+	Name string `json:"country"`
+	casecount.LocationAndPopulation
+	Days      []timelineDay `json:"days"`
+	NewsError *apiError     `json:"newsError,omitempty"`
+}
+
+// timelineDay : case counts of a day and the articles published on it in UTC, from the latest to the earliest unless the providers sort them otherwise
+type timelineDay struct {
+	casecount.CaseCount
+	Articles []news.Article `json:"articles"`
+}
+
+// parseURLQueryValues : get all the non empty values of a query parameter that can be given more than once, with a case insensitive key.
+// The raw query is read pair by pair, because the keys of URL.Query() are in random order and the values must stay in the order they were given
+func parseURLQueryValues(URL *url.URL, key string) []string {
+	var values []string
+	for _, pair := range strings.Split(URL.RawQuery, "&") {
+		rawKey, rawValue := pair, ""
+		if i := strings.Index(pair, "="); i >= 0 {
+			rawKey, rawValue = pair[:i], pair[i+1:]
+		}
+		k, keyErr := url.QueryUnescape(rawKey)
+		value, valueErr := url.QueryUnescape(rawValue)
+		if keyErr != nil || valueErr != nil || strings.ToLower(k) != key || value == "" {
+			continue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
 func parseURLQuery(URL *url.URL, key string) string {
 	query := URL.Query()
 	for k, v := range query {
@@ -63,54 +343,245 @@ func parseURLQuery(URL *url.URL, key string) string {
 	return ""
 }
 
-func getCaseCountsResponse(from string, to string, country string, aggregateCountries bool, perDay bool, worldTotal bool) ([]byte, error, error) {
-	if worldTotal {
-		caseCounts, caseCountsErr := casecount.GetWorldCaseCounts(from, to)
+// toCaseCountsError : map an error from a case count query to the response for the client, which is always caused by the query
+func toCaseCountsError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return newInvalidParameterError("", err)
+}
+
+func getCaseCountsResponse(params queryParams) ([]byte, error, error) {
+	if params.interval != "" && !params.perDay && !params.worldTotal {
+		return nil, nil, newInvalidParameterError("interval", errors.New("Interval can only be used together with perDay or worldTotal"))
+	}
+	if len(params.states) > 0 && (params.aggregateCountries || params.worldTotal) {
+		return nil, nil, newInvalidParameterError("state", errors.New("State cannot be used together with aggregateCountries or worldTotal"))
+	}
+	if !casecount.IsLoaded() {
+		return nil, nil, newDataNotLoadedError("The case counts have not been loaded yet, please try again later")
+	}
+	if params.worldTotal {
+		caseCounts, caseCountsErr := casecount.GetWorldCaseCounts(params.from, params.to, params.interval, params.dateFormat)
 		response, err := json.Marshal(caseCounts)
-		return response, err, caseCountsErr
+		return response, err, toCaseCountsError(caseCountsErr)
 	}
-	if perDay {
-		if aggregateCountries {
-			caseCounts, caseCountsErr := casecount.GetCountryCaseCountsWithDayData(from, to, country)
+	if params.perDay {
+		if params.aggregateCountries {
+			caseCounts, caseCountsErr := casecount.GetCountryCaseCountsWithDayData(params.from, params.to, params.country, params.interval, params.dateFormat)
 			response, err := json.Marshal(caseCounts)
-			return response, err, caseCountsErr
+			return response, err, toCaseCountsError(caseCountsErr)
 		}
-		caseCounts, caseCountsErr := casecount.GetCaseCountsWithDayData(from, to, country)
+		caseCounts, caseCountsErr := casecount.GetCaseCountsWithDayData(params.from, params.to, params.country, params.states, params.interval, params.dateFormat)
 		response, err := json.Marshal(caseCounts)
-		return response, err, caseCountsErr
+		return response, err, toCaseCountsError(caseCountsErr)
 	}
-	if aggregateCountries {
-		caseCounts, caseCountsErr := casecount.GetCountryCaseCounts(from, to, country)
+	if params.aggregateCountries {
+		caseCounts, caseCountsErr := casecount.GetCountryCaseCounts(params.from, params.to, params.country)
 		response, err := json.Marshal(caseCounts)
-		return response, err, caseCountsErr
+		return response, err, toCaseCountsError(caseCountsErr)
 	}
-	caseCounts, caseCountsErr := casecount.GetCaseCounts(from, to, country)
+	caseCounts, caseCountsErr := casecount.GetCaseCounts(params.from, params.to, params.country, params.states)
 	response, err := json.Marshal(caseCounts)
-	return response, err, caseCountsErr
+	return response, err, toCaseCountsError(caseCountsErr)
+}
+
+// getCountriesResponse : metadata of every country and its states, or only of the country in the query
+func getCountriesResponse(params queryParams) ([]byte, error, error) {
+	if !casecount.IsLoaded() {
+		return nil, nil, newDataNotLoadedError("The countries have not been loaded yet, please try again later")
+	}
+	metadata, metadataErr := casecount.GetCountryMetadata(params.country, params.dateFormat)
+	response, err := json.Marshal(metadata)
+	return response, err, toCaseCountsError(metadataErr)
+}
+
+// getCountryResponse : metadata of the country in the query and its states, which must have case counts
+func getCountryResponse(params queryParams) ([]byte, error, error) {
+	if !casecount.IsLoaded() {
+		return nil, nil, newDataNotLoadedError("The countries have not been loaded yet, please try again later")
+	}
+	metadata, metadataErr := casecount.GetCountryMetadata(params.country, params.dateFormat)
+	if metadataErr != nil {
+		return nil, nil, toCaseCountsError(metadataErr)
+	}
+	countryMetadata, ok := metadata[params.country]
+	if !ok {
+		return nil, nil, newCountryNotFoundError(fmt.Sprintf("Country %s has no case counts", params.country), nil)
+	}
+	response, err := json.Marshal(countryMetadata)
+	return response, err, nil
+}
+
+// getAutocompleteResponse : countries, states and US counties that complete the query, ranked from the best match
+func getAutocompleteResponse(params queryParams) ([]byte, error, error) {
+	if params.query == "" {
+		return nil, nil, newInvalidParameterError("q", errors.New("Query q must be given with the start of the name or code of a location"))
+	}
+	limit := params.limit
+	if limit == 0 {
+		limit = defaultAutocompleteLimit
+	}
+	response, err := json.Marshal(utils.Locations.Autocomplete(params.query, limit))
+	return response, err, nil
+}
+
+// withPathCountry : get URL with the country query parameter set to the path segment after prefix, such as SG for /countries/SG
+func withPathCountry(URL *url.URL, prefix string) *url.URL {
+	country := strings.Trim(strings.TrimPrefix(URL.Path, prefix), "/")
+	query := URL.Query()
+	for key := range query {
+		if strings.ToLower(key) == "country" {
+			query.Del(key)
+		}
+	}
+	query.Set("country", country)
+	newURL := *URL
+	newURL.RawQuery = query.Encode()
+	return &newURL
+}
+
+// toNewsError : map an error from the news to the response for the client. Errors of the News API are rate limits, rejected parameters
+// or failures of the News API itself, and any other error is a failure of a news source
+func toNewsError(err error) error {
+	if errors.Is(err, news.ErrNotConfigured) {
+		return newNewsUnavailableError(err.Error())
+	}
+	var newsErr *news.APIError
+	if !errors.As(err, &newsErr) {
+		return newUpstreamError(err)
+	}
+	if newsErr.IsRateLimited() {
+		return newRateLimitedError("The News API has been called too often, please try again later: " + newsErr.Message)
+	}
+	if newsErr.IsInvalidParameter() {
+		return newInvalidParameterError("", fmt.Errorf("The News API rejected the query: %s", newsErr.Message))
+	}
+	return newUpstreamError(newsErr)
+}
+
+// getNewsForCountryResponse : get a page of the news for the query, with the total number of articles and the next page
+func getNewsForCountryResponse(params queryParams) ([]byte, error, error) {
+	if !isNewsAvailable() {
+		return nil, nil, newNewsUnavailableError(news.ErrNotConfigured.Error())
+	}
+	if params.country != "" && params.sortBy != "" {
+		return nil, nil, newInvalidParameterError("sortBy", errors.New("Only one of country and sortBy can be given, news can only be sorted when it is from every country"))
+	}
+	if params.country != "" && !news.IsSupportedCountry(params.country) {
+		return nil, nil, newInvalidParameterError("country", fmt.Errorf("There is no news for %s", params.country))
+	}
+	page, newsErr := news.GetNews(news.Query{From: params.from, To: params.to, Country: params.country, Keywords: params.query, Language: params.language, SortBy: params.sortBy}, params.page, params.pageSize)
+	if newsErr != nil {
+		return nil, nil, toNewsError(newsErr)
+	}
+	response, err := json.Marshal(page)
+	return response, err, nil
+}
+
+// getTimelineNews : get every article for the country and dates of the query, or none if there is no news for the country.
+// Dates are in the format of the case counts, so they are converted to the format of the news
+func getTimelineNews(params queryParams) ([]news.Article, error) {
+	if !isNewsAvailable() || !news.IsSupportedCountry(params.country) {
+		return nil, nil
+	}
+	from, err := dateformat.FormatDate(dateformat.NewsDateFormat, dateformat.FormatJHU, params.from)
+	if err != nil {
+		return nil, newInvalidParameterError("from", err)
+	}
+	to, err := dateformat.FormatDate(dateformat.NewsDateFormat, dateformat.FormatJHU, params.to)
+	if err != nil {
+		return nil, newInvalidParameterError("to", err)
+	}
+	articles, err := news.GetAllNews(news.Query{From: from, To: to, Country: params.country, Keywords: params.query, Language: params.language})
+	if err != nil {
+		return nil, toNewsError(err)
+	}
+	return articles, nil
+}
+
+// getTimeline : put each article on the day of the case counts of the country that it was published on, where the dates of the counts are in dateFormat.
+// Articles published on days without case counts, or without a valid publication date, are left out. newsErr is the error of getting the articles, if any
+func getTimeline(country casecount.Country, articles []news.Article, newsErr error, dateFormat string) timelineResponse {
+	articlesByDate := make(map[string][]news.Article)
+	for _, article := range articles {
+		if day, ok := article.GetPublishedDay(); ok {
+			date := dateformat.FormatOutputDate(dateFormat, day)
+			articlesByDate[date] = append(articlesByDate[date], article)
+		}
+	}
+	days := make([]timelineDay, len(country.Counts))
+	for i, count := range country.Counts {
+		dayArticles := articlesByDate[count.Date]
+		if dayArticles == nil {
+			dayArticles = []news.Article{}
+		}
+		days[i] = timelineDay{count, dayArticles}
+	}
+	timeline := timelineResponse{country.Name, country.LocationAndPopulation, days, nil}
+	if newsErr != nil {
+		timeline.NewsError = toAPIError(newsErr)
+	}
+	return timeline
+}
+
+// getTimelineResponse : case counts of the country in the query for each day between its dates, with the news published on each day.
+// The case counts are still returned if the news fails
+func getTimelineResponse(params queryParams) ([]byte, error, error) {
+	if params.country == "" {
+		return nil, nil, newInvalidParameterError("country", errors.New("Country must be given to get its timeline"))
+	}
+	if !casecount.IsLoaded() {
+		return nil, nil, newDataNotLoadedError("The case counts have not been loaded yet, please try again later")
+	}
+	caseCounts, caseCountsErr := casecount.GetCountryCaseCountsWithDayData(params.from, params.to, params.country, "", params.dateFormat)
+	if caseCountsErr != nil {
+		return nil, nil, toCaseCountsError(caseCountsErr)
+	}
+	country, ok := caseCounts[params.country]
+	if !ok {
+		return nil, nil, newCountryNotFoundError(fmt.Sprintf("Country %s has no case counts", params.country), nil)
+	}
+	articles, newsErr := getTimelineNews(params)
+	if newsErr != nil {
+		log.Printf("Timeline of %s is returned without news: %s\n", params.country, newsErr.Error())
+	}
+	response, err := json.Marshal(getTimeline(country, articles, newsErr, params.dateFormat))
+	return response, err, nil
+}
+
+// getStatusResponse : whether the case counts have been loaded and whether news is available, which never fails
+func getStatusResponse(params queryParams) ([]byte, error, error) {
+	status := statusResponse{caseCountsStatus{}, newsStatus{isNewsAvailable(), getNewsProviders()}}
+	if lastDate, ok := getLastDate(); ok {
+		status.CaseCounts = caseCountsStatus{true, dateformat.FormatOutputDate(params.dateFormat, lastDate)}
+	}
+	response, err := json.Marshal(status)
+	return response, err, nil
 }
 
-func getNewsForCountryResponse(from string, to string, country string, _ bool, _ bool, _ bool) ([]byte, error, error) {
-	articles, newsErr := news.GetNews(from, to, country)
-	response, err := json.Marshal(articles)
-	return response, err, newsErr
+// getDocsResponse : every endpoint with the query parameters that it accepts, which never fails
+func getDocsResponse(params queryParams) ([]byte, error, error) {
+	response, err := json.Marshal(endpoints)
+	return response, err, nil
 }
 
-func getResponse(getDataFn func(from string, to string, country string, aggregateCountries bool, perDay bool, worldTotal bool) ([]byte, error, error), w writer, URL *url.URL, getCountryAbbreviation bool) {
+// getResponse : write the response of getDataFn for the query of the endpoint, or the error if the query is not valid or getDataFn fails
+func getResponse(getDataFn func(params queryParams) ([]byte, error, error), w writer, URL *url.URL, e endpoint) {
 	log.Println(URL.String())
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	from, to, country, aggregateCountries, perDay, worldTotal, err := parseURL(URL, dateformat.CasesDateFormat)
+	params, err := parseURL(URL, e)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
-	response, jsonErr, internalErr := getDataFn(from, to, country, aggregateCountries, perDay, worldTotal)
+	response, jsonErr, internalErr := getDataFn(params)
 	if internalErr != nil {
-		http.Error(w, internalErr.Error(), http.StatusBadRequest)
+		writeError(w, internalErr)
 		return
 	}
 	if jsonErr != nil {
-		http.Error(w, jsonErr.Error(), http.StatusInternalServerError)
+		writeError(w, jsonErr)
 		return
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -2,14 +2,54 @@ package requests
 
 import (
 	"net/http"
+	"strings"
 )
 
+// countriesPathPrefix : path of the endpoint for a single country, which is followed by the country
+const countriesPathPrefix = "/countries/"
+
 // GetCaseCounts : logic when /cases endpoint is called. Returns all aggregated confirmed cases/death counts between from and to dates in the query
 func GetCaseCounts(w http.ResponseWriter, r *http.Request) {
-	getResponse(getCaseCountsResponse, w, r.URL, false)
+	getResponse(getCaseCountsResponse, w, r.URL, casesEndpoint)
 }
 
 // GetNewsForCountry : runs query to get all virus related news for a given country
 func GetNewsForCountry(w http.ResponseWriter, r *http.Request) {
-	getResponse(getNewsForCountryResponse, w, r.URL, true)
+	getResponse(getNewsForCountryResponse, w, r.URL, newsEndpoint)
+}
+
+// GetCountries : logic when /countries endpoint is called. Returns the countries and states that there are case counts for, with their codes,
+// location, population and range of dates with data
+func GetCountries(w http.ResponseWriter, r *http.Request) {
+	getResponse(getCountriesResponse, w, r.URL, countriesEndpoint)
+}
+
+// GetCountry : logic when /countries/{country} endpoint is called. Returns the same information as /countries for only that country
+func GetCountry(w http.ResponseWriter, r *http.Request) {
+	if strings.Trim(strings.TrimPrefix(r.URL.Path, countriesPathPrefix), "/") == "" {
+		GetCountries(w, r)
+		return
+	}
+	getResponse(getCountryResponse, w, withPathCountry(r.URL, countriesPathPrefix), countryEndpoint)
+}
+
+// GetAutocomplete : logic when /autocomplete endpoint is called. Returns the countries, states and US counties that complete the name or code in the query
+func GetAutocomplete(w http.ResponseWriter, r *http.Request) {
+	getResponse(getAutocompleteResponse, w, r.URL, autocompleteEndpoint)
+}
+
+// GetTimeline : logic when /timeline endpoint is called. Returns the case counts of the country for each day between from and to dates in the query,
+// with the news published on each day
+func GetTimeline(w http.ResponseWriter, r *http.Request) {
+	getResponse(getTimelineResponse, w, r.URL, timelineEndpoint)
+}
+
+// GetStatus : logic when /status endpoint is called. Returns whether the case counts have been loaded and whether news is available
+func GetStatus(w http.ResponseWriter, r *http.Request) {
+	getResponse(getStatusResponse, w, r.URL, statusEndpoint)
+}
+
+// GetDocs : logic when /docs endpoint is called. Returns every endpoint with the query parameters that it accepts
+func GetDocs(w http.ResponseWriter, r *http.Request) {
+	getResponse(getDocsResponse, w, r.URL, docsEndpoint)
 }
@@ -1,17 +1,24 @@
 package requests
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 	"yet-another-covid-map-api/casecount"
 	"yet-another-covid-map-api/dateformat"
+	"yet-another-covid-map-api/news"
+	"yet-another-covid-map-api/utils"
 )
 
 var (
-	fakeResponse []byte
-	testFnCalled bool
+	fakeResponse   []byte
+	fakeStatusCode int
+	testFnCalled   bool
 )
 
 type fakeWriter struct{}
@@ -26,10 +33,10 @@ func (w *fakeWriter) Write(response []byte) (int, error) {
 }
 
 func (w *fakeWriter) WriteHeader(statusCode int) {
-	return
+	fakeStatusCode = statusCode
 }
 
-func callTestFn(from string, to string, country string, aggregateCountries bool, perDay bool, worldTotal bool) ([]byte, error, error) {
+func callTestFn(params queryParams) ([]byte, error, error) {
 	testFnCalled = true
 	return []byte("response"), nil, nil
 }
@@ -43,47 +50,54 @@ func TestParseUrlQuery(t *testing.T) {
 		aggregateCountries bool
 		perDay             bool
 		worldTotal         bool
+		interval           string
 		errorString        string
 	}{
-		{"http://localhost:8080/cases", "", "", "", false, false, false, ""},
-		{"http://localhost:8080/cases?from=&to=1/1/20", "", "1/1/20", "", false, false, false, ""},
-		{"http://localhost:8080/cases?from=1/1/20&to=1/2/20", "1/1/20", "1/2/20", "", false, false, false, ""},
-		{"http://localhost:8080/cases?from=&to=1/1/20", "", "1/1/20", "", false, false, false, ""},
-		{"http://localhost:8080/cases?from=&to=&country=CN", "", "", "CN", false, false, false, ""},
-		{"http://localhost:8080/cases?from=&to=&country=gb", "", "", "GB", false, false, false, ""},
-		{"http://localhost:8080/cases?country=United Kingdom", "", "", "GB", false, false, false, ""},
-		{"http://localhost:8080/cases?aggregateCountries=true&country=sg", "", "", "SG", true, false, false, ""},
-		{"http://localhost:8080/cases?aggregatecountries=true&country=sg", "", "", "SG", true, false, false, ""},
-		{"http://localhost:8080/cases?aggregateCountries=tru&country=Singapore", "", "", "SG", false, false, false, ""},
-		{"http://localhost:8080/cases?aggregateCountries=tru&country=Sngapore", "", "", "", false, false, false, "Singapore"},
-		{"http://localhost:8080/cases?from=1/1/20&to=1/2/20&country=Singapore&aggregateCountries=true&perDay=false", "1/1/20", "1/2/20", "SG", true, false, false, ""},
-		{"http://localhost:8080/cases?from=1/1/20&to=1/2/20&country=Singapore&aggregateCountries=false&perDay=true", "1/1/20", "1/2/20", "SG", false, true, false, ""},
-		{"http://localhost:8080/cases?from=1/32/20&to=1/2/20&country=Singapore&aggregateCountries=true", "", "", "", false, false, false, "Date format"},
-		{"http://localhost:8080/cases?from=1/1/20&to=1/32/20&country=Singapore&aggregateCountries=true", "", "", "", false, false, false, "Date format"},
-		{"http://localhost:8080/cases?from=1/1/20&to=1/30/20&worldTotal=true", "1/1/20", "1/30/20", "", false, false, true, ""},
-		{"http://localhost:8080/cases?from=1/1/20&to=1/30/20&worldTotal=false", "1/1/20", "1/30/20", "", false, false, false, ""},
+		{"http://localhost:8080/cases", "", "", "", false, false, false, "", ""},
+		{"http://localhost:8080/cases?from=&to=1/1/20", "", "1/1/20", "", false, false, false, "", ""},
+		{"http://localhost:8080/cases?from=1/1/20&to=1/2/20", "1/1/20", "1/2/20", "", false, false, false, "", ""},
+		{"http://localhost:8080/cases?from=&to=1/1/20", "", "1/1/20", "", false, false, false, "", ""},
+		{"http://localhost:8080/cases?from=&to=&country=CN", "", "", "CN", false, false, false, "", ""},
+		{"http://localhost:8080/cases?from=&to=&country=gb", "", "", "GB", false, false, false, "", ""},
+		{"http://localhost:8080/cases?country=United Kingdom", "", "", "GB", false, false, false, "", ""},
+		{"http://localhost:8080/cases?aggregateCountries=true&country=sg", "", "", "SG", true, false, false, "", ""},
+		{"http://localhost:8080/cases?aggregatecountries=true&country=sg", "", "", "SG", true, false, false, "", ""},
+		{"http://localhost:8080/cases?aggregateCountries=tru&country=Singapore", "", "", "SG", false, false, false, "", ""},
+		{"http://localhost:8080/cases?aggregateCountries=tru&country=Sngapore", "", "", "", false, false, false, "", "Singapore"},
+		{"http://localhost:8080/cases?from=1/1/20&to=1/2/20&country=Singapore&aggregateCountries=true&perDay=false", "1/1/20", "1/2/20", "SG", true, false, false, "", ""},
+		{"http://localhost:8080/cases?from=1/1/20&to=1/2/20&country=Singapore&aggregateCountries=false&perDay=true", "1/1/20", "1/2/20", "SG", false, true, false, "", ""},
+		{"http://localhost:8080/cases?from=1/32/20&to=1/2/20&country=Singapore&aggregateCountries=true", "", "", "", false, false, false, "", "Date format"},
+		{"http://localhost:8080/cases?from=1/1/20&to=1/32/20&country=Singapore&aggregateCountries=true", "", "", "", false, false, false, "", "Date format"},
+		{"http://localhost:8080/cases?from=1/1/20&to=1/30/20&worldTotal=true", "1/1/20", "1/30/20", "", false, false, true, "", ""},
+		{"http://localhost:8080/cases?from=1/1/20&to=1/30/20&worldTotal=false", "1/1/20", "1/30/20", "", false, false, false, "", ""},
+		{"http://localhost:8080/cases?perDay=true&interval=week", "", "", "", false, true, false, "week", ""},
+		{"http://localhost:8080/cases?worldTotal=true&interval=EpiWeek", "", "", "", false, false, true, "epiweek", ""},
+		{"http://localhost:8080/cases?perDay=true&interval=fortnight", "", "", "", false, false, false, "", "Interval fortnight is not recognised"},
 	}
 
 	for _, table := range tables {
 		url, _ := url.Parse(table.rawurl)
-		from, to, country, aggregateCountries, perDay, worldTotal, err := parseURL(url, dateformat.CasesDateFormat)
-		if from != table.from {
-			t.Errorf("from result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, from, table.from)
+		params, err := parseURL(url, casesEndpoint)
+		if params.from != table.from {
+			t.Errorf("from result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.from, table.from)
 		}
-		if to != table.to {
-			t.Errorf("to result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, to, table.to)
+		if params.to != table.to {
+			t.Errorf("to result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.to, table.to)
 		}
-		if country != table.country {
-			t.Errorf("country result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, country, table.country)
+		if params.country != table.country {
+			t.Errorf("country result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.country, table.country)
 		}
-		if aggregateCountries != table.aggregateCountries {
-			t.Errorf("aggregateCountries result of parseURL was incorrect for %s, got: %t, want: %t.", table.rawurl, aggregateCountries, table.aggregateCountries)
+		if params.aggregateCountries != table.aggregateCountries {
+			t.Errorf("aggregateCountries result of parseURL was incorrect for %s, got: %t, want: %t.", table.rawurl, params.aggregateCountries, table.aggregateCountries)
 		}
-		if perDay != table.perDay {
-			t.Errorf("perDay result of parseURL was incorrect for %s, got: %t, want: %t.", table.rawurl, perDay, table.perDay)
+		if params.perDay != table.perDay {
+			t.Errorf("perDay result of parseURL was incorrect for %s, got: %t, want: %t.", table.rawurl, params.perDay, table.perDay)
 		}
-		if worldTotal != table.worldTotal {
-			t.Errorf("worldTotal result of parseURL was incorrect for %s, got: %t, want: %t.", table.rawurl, worldTotal, table.worldTotal)
+		if params.worldTotal != table.worldTotal {
+			t.Errorf("worldTotal result of parseURL was incorrect for %s, got: %t, want: %t.", table.rawurl, params.worldTotal, table.worldTotal)
+		}
+		if params.interval != table.interval {
+			t.Errorf("interval result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.interval, table.interval)
 		}
 		if table.errorString != "" && !strings.Contains(err.Error(), table.errorString) {
 			t.Errorf("error thrown for parseURL was incorrect for %s, got: %s, want error containing: %s.", table.rawurl, err.Error(), table.errorString)
@@ -91,6 +105,218 @@ func TestParseUrlQuery(t *testing.T) {
 	}
 }
 
+func TestParseUrlQuery_RelativeDates(t *testing.T) {
+	defer func() { getLastDate = casecount.GetLastDate }()
+	getLastDate = func() (time.Time, bool) {
+		return time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC), true
+	}
+	tables := []struct {
+		rawurl      string
+		from        string
+		to          string
+		errorString string
+	}{
+		{"http://localhost:8080/cases?from=-14d", "3/17/20", "", ""},
+		{"http://localhost:8080/cases?from=latest-1w&to=latest", "3/24/20", "3/31/20", ""},
+		{"http://localhost:8080/cases?from=2020-03-01&to=-1d", "3/1/20", "3/30/20", ""},
+		{"http://localhost:8080/cases?last=14", "3/18/20", "3/31/20", ""},
+		{"http://localhost:8080/cases?last=1&to=3/20/20", "3/20/20", "3/20/20", ""},
+		{"http://localhost:8080/cases?last=7&to=3/20/20", "3/14/20", "3/20/20", ""},
+		{"http://localhost:8080/cases?last=7&to=latest-1w", "3/18/20", "3/24/20", ""},
+		{"http://localhost:8080/cases?last=7&to=3/32/20", "", "", "Date format is not recognised"},
+		{"http://localhost:8080/cases?last=14&from=3/1/20", "", "", "Only one of from and last"},
+		{"http://localhost:8080/cases?last=0", "", "", "Last 0 is not valid"},
+		{"http://localhost:8080/cases?last=two", "", "", "Last two is not valid"},
+		{"http://localhost:8080/cases?from=latest-1y", "", "", "Date format is not recognised"},
+	}
+	for _, table := range tables {
+		url, _ := url.Parse(table.rawurl)
+		params, err := parseURL(url, casesEndpoint)
+		if params.from != table.from {
+			t.Errorf("from result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.from, table.from)
+		}
+		if params.to != table.to {
+			t.Errorf("to result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.to, table.to)
+		}
+		if table.errorString == "" && err != nil {
+			t.Errorf("parseURL should not have returned an error for %s, got: %s.", table.rawurl, err.Error())
+		}
+		if table.errorString != "" && (err == nil || !strings.Contains(err.Error(), table.errorString)) {
+			t.Errorf("error thrown for parseURL was incorrect for %s, got: %v, want error containing: %s.", table.rawurl, err, table.errorString)
+		}
+	}
+}
+
+func TestParseUrlQuery_LastDays(t *testing.T) {
+	defer func(today func() time.Time) { getLastDate, getToday = casecount.GetLastDate, today }(getToday)
+	getLastDate = func() (time.Time, bool) {
+		return time.Date(2022, 11, 7, 0, 0, 0, 0, time.UTC), true
+	}
+	getToday = func() time.Time {
+		return time.Date(2022, 11, 20, 0, 0, 0, 0, time.UTC)
+	}
+	tables := []struct {
+		rawurl   string
+		endpoint endpoint
+		from     string
+		to       string
+	}{
+		{"http://localhost:8080/cases?last=14", casesEndpoint, "10/25/22", "11/7/22"},
+		{"http://localhost:8080/cases?last=14&inputDateFormat=iso", casesEndpoint, "10/25/22", "11/7/22"},
+		{"http://localhost:8080/cases?last=28&inputDateFormat=jhu", casesEndpoint, "10/11/22", "11/7/22"},
+		{"http://localhost:8080/news?last=7&inputDateFormat=iso", newsEndpoint, "2022-11-14", "2022-11-20"},
+		{"http://localhost:8080/news?last=7&to=2022-11-07", newsEndpoint, "2022-11-01", "2022-11-07"},
+	}
+	for _, table := range tables {
+		url, _ := url.Parse(table.rawurl)
+		params, err := parseURL(url, table.endpoint)
+		if err != nil || params.from != table.from || params.to != table.to {
+			t.Errorf("Dates of parseURL were incorrect for %s, got: %s %s %v, want: %s %s.", table.rawurl, params.from, params.to, err, table.from, table.to)
+		}
+	}
+}
+
+func TestParseUrlQuery_DateFormats(t *testing.T) {
+	tables := []struct {
+		rawurl      string
+		from        string
+		dateFormat  string
+		errorString string
+	}{
+		{"http://localhost:8080/cases?from=2020-01-02&dateFormat=ISO", "1/2/20", "iso", ""},
+		{"http://localhost:8080/cases?from=1577923200&dateFormat=unix", "1/2/20", "unix", ""},
+		{"http://localhost:8080/cases?from=01/02/03", "1/2/03", "jhu", ""},
+		{"http://localhost:8080/cases?from=01/02/03&inputDateFormat=jhu", "1/2/03", "jhu", ""},
+		{"http://localhost:8080/cases?from=01/02/03&inputDateFormat=ymd", "2/3/01", "jhu", ""},
+		{"http://localhost:8080/cases?from=2020-01-02&inputDateFormat=jhu", "", "", "not in the jhu format"},
+		{"http://localhost:8080/cases?dateFormat=ymd", "", "", "Date format ymd is not recognised"},
+		{"http://localhost:8080/cases?inputDateFormat=dmy", "", "", "Input date format dmy is not recognised"},
+	}
+	for _, table := range tables {
+		url, _ := url.Parse(table.rawurl)
+		params, err := parseURL(url, casesEndpoint)
+		if params.from != table.from {
+			t.Errorf("from result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.from, table.from)
+		}
+		if params.dateFormat != table.dateFormat {
+			t.Errorf("dateFormat result of parseURL was incorrect for %s, got: %s, want: %s.", table.rawurl, params.dateFormat, table.dateFormat)
+		}
+		if table.errorString == "" && err != nil {
+			t.Errorf("parseURL should not have returned an error for %s, got: %s.", table.rawurl, err.Error())
+		}
+		if table.errorString != "" && (err == nil || !strings.Contains(err.Error(), table.errorString)) {
+			t.Errorf("error thrown for parseURL was incorrect for %s, got: %v, want error containing: %s.", table.rawurl, err, table.errorString)
+		}
+	}
+}
+
+func setTestLocations() func() {
+	locations, abbreviationToCountry, countryToAbbreviation := utils.Locations, utils.AbbreviationToCountry, utils.CountryToAbbreviation
+	utils.Locations = utils.NewLocationLookup([][]string{
+		[]string{"840", "US", "USA", "840", "", "", "", "US", "40", "-100", "US", "329466283"},
+		[]string{"84000036", "US", "USA", "840", "36", "", "New York", "US", "42.1657", "-74.9481", "New York, US", "19453561"},
+		[]string{"84036061", "US", "USA", "840", "36061", "New York", "New York", "US", "40.7672", "-73.9715", "New York City, New York, US", "1628706"},
+		[]string{"156", "CN", "CHN", "156", "", "", "", "China", "35.8617", "104.1954", "China", "1404676330"},
+		[]string{"15617", "CN", "CHN", "156", "", "", "Hubei", "China", "30.9756", "112.2707", "Hubei, China", "57237740"},
+		[]string{"15611", "CN", "CHN", "156", "", "", "Beijing", "China", "40.1824", "116.4142", "Beijing, China", "21540000"},
+		[]string{"124", "CA", "CAN", "124", "", "", "", "Canada", "60", "-95", "Canada", "37855702"},
+		[]string{"12490", "CA", "CAN", "124", "", "", "Diamond Princess", "Canada", "", "", "Diamond Princess, Canada", ""},
+		[]string{"84088888", "US", "USA", "840", "88888", "", "Diamond Princess", "US", "", "", "Diamond Princess, US", ""},
+	})
+	utils.AbbreviationToCountry = map[string]string{"US": "US", "CN": "China", "CA": "Canada"}
+	utils.CountryToAbbreviation = map[string]string{"US": "US", "China": "CN", "Canada": "CA"}
+	return func() {
+		utils.Locations, utils.AbbreviationToCountry, utils.CountryToAbbreviation = locations, abbreviationToCountry, countryToAbbreviation
+	}
+}
+
+func TestParseUrlQuery_StateAndFIPS(t *testing.T) {
+	defer setTestLocations()()
+	tables := []struct {
+		rawurl         string
+		country        string
+		states         string
+		expectedStatus int
+		parameter      string
+	}{
+		{"http://localhost:8080/cases?country=US&state=new york", "US", "New York", 0, ""},
+		{"http://localhost:8080/cases?country=USA&state=36", "US", "New York", 0, ""},
+		{"http://localhost:8080/cases?country=156&state=15617", "CN", "Hubei", 0, ""},
+		{"http://localhost:8080/cases?fips=36", "US", "New York", 0, ""},
+		{"http://localhost:8080/cases?fips=036&country=US", "US", "New York", 0, ""},
+		{"http://localhost:8080/cases?state=Hubei", "CN", "Hubei", 0, ""},
+		{"http://localhost:8080/cases?state=hubei&State=BEIJING&state=Hubei", "CN", "Hubei,Beijing", 0, ""},
+		{"http://localhost:8080/cases?state=Diamond Princess&country=CA", "CA", "Diamond Princess", 0, ""},
+		{"http://localhost:8080/cases?state=Diamond Princess", "", "", http.StatusBadRequest, "country"},
+		{"http://localhost:8080/cases?state=Hubei&state=New York", "", "", http.StatusBadRequest, "state"},
+		{"http://localhost:8080/cases?state=Hubie", "", "", http.StatusNotFound, "state"},
+		{"http://localhost:8080/cases?country=CN&state=New York", "", "", http.StatusNotFound, "state"},
+		{"http://localhost:8080/cases?fips=36061", "", "", http.StatusBadRequest, "fips"},
+		{"http://localhost:8080/cases?fips=99", "", "", http.StatusNotFound, "fips"},
+		{"http://localhost:8080/cases?fips=36&country=CN", "", "", http.StatusBadRequest, "fips"},
+		{"http://localhost:8080/cases?fips=36&state=New York", "", "", http.StatusBadRequest, "fips"},
+	}
+	for _, table := range tables {
+		url, _ := url.Parse(table.rawurl)
+		params, err := parseURL(url, casesEndpoint)
+		if states := strings.Join(params.states, ","); params.country != table.country || states != table.states {
+			t.Errorf("Result of parseURL was incorrect for %s, got: %s %s, want: %s %s.", table.rawurl, params.country, states, table.country, table.states)
+		}
+		if table.expectedStatus == 0 {
+			if err != nil {
+				t.Errorf("parseURL should not have returned an error for %s, got: %s.", table.rawurl, err.Error())
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("parseURL should have returned an error for %s.", table.rawurl)
+			continue
+		}
+		if apiErr := toAPIError(err); apiErr.status != table.expectedStatus || apiErr.Parameter != table.parameter {
+			t.Errorf("Error was incorrect for %s, got: %d %s, want: %d %s.", table.rawurl, apiErr.status, apiErr.Parameter, table.expectedStatus, table.parameter)
+		}
+	}
+}
+
+func TestParseUrlQuery_StateSuggestions(t *testing.T) {
+	defer setTestLocations()()
+	url, _ := url.Parse("http://localhost:8080/cases?country=CN&state=Hubie")
+	_, err := parseURL(url, casesEndpoint)
+	apiErr := toAPIError(err)
+	if apiErr.Code != codeStateNotFound || len(apiErr.Suggestions) != 1 || apiErr.Suggestions[0].Value != "Hubei" {
+		t.Errorf("Error should suggest Hubei first, got: %+v.", apiErr)
+	}
+	if !strings.Contains(apiErr.Message, "did you mean: Hubei?") {
+		t.Errorf("Error message was incorrect, got: %s, want message containing: did you mean: Hubei?", apiErr.Message)
+	}
+}
+
+func TestGetCaseCountsResponse_StateWithAggregateCountries(t *testing.T) {
+	_, _, caseCountErr := getCaseCountsResponse(queryParams{country: "US", states: []string{"New York"}, aggregateCountries: true})
+	if caseCountErr == nil || toAPIError(caseCountErr).Parameter != "state" {
+		t.Errorf("caseCountErr should be about the state parameter, got: %v.", caseCountErr)
+	}
+}
+
+func TestParseUrlQuery_RelativeDatesBeforeDataIsLoaded(t *testing.T) {
+	defer func() { getLastDate = casecount.GetLastDate }()
+	getLastDate = func() (time.Time, bool) {
+		return time.Time{}, false
+	}
+	for _, rawurl := range []string{"http://localhost:8080/cases?from=-14d", "http://localhost:8080/cases?last=14"} {
+		url, _ := url.Parse(rawurl)
+		if _, err := parseURL(url, casesEndpoint); err == nil || !strings.Contains(err.Error(), "until the case counts have been loaded") {
+			t.Errorf("error thrown for parseURL was incorrect for %s, got: %v, want error about case counts not being loaded.", rawurl, err)
+		}
+	}
+	for _, rawurl := range []string{"http://localhost:8080/news?from=-14d", "http://localhost:8080/news?last=14"} {
+		url, _ := url.Parse(rawurl)
+		if _, err := parseURL(url, newsEndpoint); err != nil {
+			t.Errorf("parseURL should not have returned an error for %s, got: %s.", rawurl, err.Error())
+		}
+	}
+}
+
 func TestGetCaseCountsResponse_PerDay(t *testing.T) {
 
 	tables := []struct {
@@ -98,20 +324,25 @@ func TestGetCaseCountsResponse_PerDay(t *testing.T) {
 		aggregateCountries bool
 		perDay             bool
 		worldTotal         bool
+		interval           string
 	}{
-		{"", false, true, false},
-		{"SG", false, true, false},
-		{"", true, false, false},
-		{"SG", true, false, false},
-		{"", false, false, false},
-		{"", true, true, false},
-		{"SG", false, false, false},
-		{"", false, false, true},
+		{"", false, true, false, ""},
+		{"SG", false, true, false, ""},
+		{"", true, false, false, ""},
+		{"SG", true, false, false, ""},
+		{"", false, false, false, ""},
+		{"", true, true, false, ""},
+		{"SG", false, false, false, ""},
+		{"", false, false, true, ""},
+		{"SG", false, true, false, casecount.IntervalWeek},
+		{"", true, true, false, casecount.IntervalMonth},
+		{"", false, false, true, casecount.IntervalEpiWeek},
 	}
 
 	for _, table := range tables {
 		casecount.UpdateCaseCounts()
-		response, err, caseCountErr := getCaseCountsResponse("", "", table.country, table.aggregateCountries, table.perDay, table.worldTotal)
+		response, err, caseCountErr := getCaseCountsResponse(queryParams{country: table.country, aggregateCountries: table.aggregateCountries, perDay: table.perDay,
+			worldTotal: table.worldTotal, interval: table.interval})
 		if len(response) < 3 {
 			t.Errorf("Response should not be empty, got length: %d, want length: %s.", len(response), "more than 2")
 		}
@@ -123,8 +354,15 @@ func TestGetCaseCountsResponse_PerDay(t *testing.T) {
 		}
 	}
 }
+func TestGetCaseCountsResponse_IntervalWithoutPerDay(t *testing.T) {
+	_, _, caseCountErr := getCaseCountsResponse(queryParams{interval: casecount.IntervalWeek})
+	if caseCountErr == nil || !strings.Contains(caseCountErr.Error(), "perDay or worldTotal") {
+		t.Errorf("caseCountErr should be about the interval needing perDay or worldTotal, got: %v.", caseCountErr)
+	}
+}
+
 func TestGetNewsForCountryResponse_PerDay(t *testing.T) {
-	response, err, newsErr := getNewsForCountryResponse("", "", "Singapore", false, false, false)
+	response, err, newsErr := getNewsForCountryResponse(queryParams{country: "SG"})
 	if len(response) < 3 {
 		t.Errorf("Response should not be empty, got length: %d, want length: %s.", len(response), "more than 2")
 	}
@@ -146,7 +384,7 @@ func TestGetResponse(t *testing.T) {
 		fakeResponse = []byte("")
 		testFnCalled = false
 		inputURL, _ := url.Parse(testURL)
-		getResponse(callTestFn, &fakeWriter{}, inputURL, true)
+		getResponse(callTestFn, &fakeWriter{}, inputURL, casesEndpoint)
 		if !testFnCalled {
 			t.Error("callTestFn should have been called, but it was not.")
 		}
@@ -158,13 +396,304 @@ func TestGetResponse(t *testing.T) {
 
 func TestGetResponse_shouldFailWhenDateIsMalformed(t *testing.T) {
 	fakeResponse = []byte("")
+	fakeStatusCode = 0
 	testFnCalled = false
 	inputURL, _ := url.Parse("http://localhost:8080/cases?from=3/32/20")
-	getResponse(callTestFn, &fakeWriter{}, inputURL, false)
+	getResponse(callTestFn, &fakeWriter{}, inputURL, casesEndpoint)
 	if testFnCalled {
 		t.Error("callTestFn should not have been called, but it was.")
 	}
 	if !strings.Contains(string(fakeResponse), "Date format is not recognised") {
 		t.Errorf("fakeResponse did not contain the correct error message, got: %s, want: string containing message about date format not recognised", fakeResponse)
 	}
+	if fakeStatusCode != http.StatusBadRequest {
+		t.Errorf("Status code was incorrect, got: %d, want: %d.", fakeStatusCode, http.StatusBadRequest)
+	}
+}
+
+func TestWithPathCountry(t *testing.T) {
+	tables := []struct {
+		rawurl  string
+		country string
+	}{
+		{"http://localhost:8080/countries/SG", "SG"},
+		{"http://localhost:8080/countries/United%20Kingdom/?dateFormat=iso", "United Kingdom"},
+		{"http://localhost:8080/countries/CN?Country=US", "CN"},
+	}
+	for _, table := range tables {
+		inputURL, _ := url.Parse(table.rawurl)
+		URL := withPathCountry(inputURL, countriesPathPrefix)
+		if country := parseURLQuery(URL, "country"); country != table.country || len(parseURLQueryValues(URL, "country")) != 1 {
+			t.Errorf("Country is incorrect for %s, got: %s, want: %s.", table.rawurl, URL.RawQuery, table.country)
+		}
+	}
+	if inputURL, _ := url.Parse("http://localhost:8080/countries/SG?dateFormat=iso"); parseURLQuery(withPathCountry(inputURL, countriesPathPrefix), "dateformat") != "iso" {
+		t.Error("Other query parameters should be kept.")
+	}
+}
+
+func TestGetCountriesResponse_NotLoaded(t *testing.T) {
+	if casecount.IsLoaded() {
+		t.Skip("Case counts are already loaded.")
+	}
+	for _, getDataFn := range []func(params queryParams) ([]byte, error, error){getCountriesResponse, getCountryResponse} {
+		_, _, countriesErr := getDataFn(queryParams{country: "SG"})
+		if countriesErr == nil || toAPIError(countriesErr).status != http.StatusServiceUnavailable {
+			t.Errorf("countriesErr should be data not loaded, got: %v.", countriesErr)
+		}
+	}
+}
+
+func TestParseUrlQuery_AutocompleteLimit(t *testing.T) {
+	tables := []struct {
+		rawurl string
+		limit  int
+		ok     bool
+	}{
+		{"http://localhost:8080/autocomplete?q=sing", defaultAutocompleteLimit, true},
+		{"http://localhost:8080/autocomplete?q=sing&limit=5", 5, true},
+		{"http://localhost:8080/autocomplete?q=sing&limit=50", 50, true},
+		{"http://localhost:8080/autocomplete?q=sing&limit=51", 0, false},
+		{"http://localhost:8080/autocomplete?q=sing&limit=0", 0, false},
+		{"http://localhost:8080/autocomplete?q=sing&limit=ten", 0, false},
+	}
+	for _, table := range tables {
+		inputURL, _ := url.Parse(table.rawurl)
+		params, err := parseURL(inputURL, autocompleteEndpoint)
+		if (err == nil) != table.ok {
+			t.Errorf("Error is incorrect for %s, got: %v, want error: %t.", table.rawurl, err, !table.ok)
+			continue
+		}
+		if err != nil && toAPIError(err).Parameter != "limit" {
+			t.Errorf("Error should be about the limit parameter for %s, got: %+v.", table.rawurl, toAPIError(err))
+		}
+		if params.limit != table.limit || (table.ok && params.query != "sing") {
+			t.Errorf("Query or limit is incorrect for %s, got: %s %d, want: %s %d.", table.rawurl, params.query, params.limit, "sing", table.limit)
+		}
+	}
+}
+
+func TestGetAutocompleteResponse(t *testing.T) {
+	defer setTestLocations()()
+	response, err, autocompleteErr := getAutocompleteResponse(queryParams{query: "new", limit: 1})
+	if err != nil || autocompleteErr != nil {
+		t.Fatalf("Errors should be nil, got: %v, %v.", err, autocompleteErr)
+	}
+	if !strings.Contains(string(response), `"uid":"84000036"`) || strings.Contains(string(response), `"uid":"84036061"`) {
+		t.Errorf("Response should only contain the state of New York, got: %s.", response)
+	}
+	if response, _, _ := getAutocompleteResponse(queryParams{query: "new"}); !strings.Contains(string(response), `"uid":"84036061"`) {
+		t.Errorf("Response should contain the county of New York with the default limit, got: %s.", response)
+	}
+	if _, _, autocompleteErr := getAutocompleteResponse(queryParams{}); autocompleteErr == nil || toAPIError(autocompleteErr).Parameter != "q" {
+		t.Errorf("autocompleteErr should be about the q parameter, got: %v.", autocompleteErr)
+	}
+}
+
+func TestParseUrlQuery_NewsParameters(t *testing.T) {
+	defer func(today func() time.Time) { getLastDate, getToday = casecount.GetLastDate, today }(getToday)
+	getLastDate = func() (time.Time, bool) {
+		return time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC), true
+	}
+	getToday = func() time.Time {
+		return time.Date(2020, 4, 15, 0, 0, 0, 0, time.UTC)
+	}
+	tables := []struct {
+		rawurl    string
+		expected  queryParams
+		parameter string
+	}{
+		{"http://localhost:8080/news?q=vaccine%20trial&language=FR&sortBy=publishedat&page=2&pageSize=50",
+			queryParams{query: "vaccine trial", language: "fr", sortBy: "publishedAt", page: 2, pageSize: 50}, ""},
+		{"http://localhost:8080/news", queryParams{language: "en", page: 1, pageSize: news.DefaultPageSize}, ""},
+		{"http://localhost:8080/news?from=3/2/20&to=latest-1d", queryParams{from: "2020-03-02", to: "2020-04-14", language: "en", page: 1, pageSize: news.DefaultPageSize}, ""},
+		{"http://localhost:8080/news?language=xx", queryParams{}, "language"},
+		{"http://localhost:8080/news?sortBy=date", queryParams{}, "sortBy"},
+		{"http://localhost:8080/news?page=0", queryParams{}, "page"},
+		{"http://localhost:8080/news?page=first", queryParams{}, "page"},
+		{"http://localhost:8080/news?pageSize=101", queryParams{}, "pageSize"},
+	}
+	for _, table := range tables {
+		inputURL, _ := url.Parse(table.rawurl)
+		params, err := parseURL(inputURL, newsEndpoint)
+		if table.parameter != "" {
+			if err == nil || toAPIError(err).Parameter != table.parameter {
+				t.Errorf("Error should be about the %s parameter for %s, got: %v.", table.parameter, table.rawurl, err)
+			}
+			continue
+		}
+		if err != nil || params.from != table.expected.from || params.to != table.expected.to || params.query != table.expected.query || params.language != table.expected.language || params.sortBy != table.expected.sortBy ||
+			params.page != table.expected.page || params.pageSize != table.expected.pageSize {
+			t.Errorf("News parameters are incorrect for %s, got: %+v %v, want: %+v.", table.rawurl, params, err, table.expected)
+		}
+	}
+}
+
+func TestGetNewsForCountryResponse_InvalidQuery(t *testing.T) {
+	defer func() { isNewsAvailable = news.IsAvailable }()
+	isNewsAvailable = func() bool { return true }
+	tables := []struct {
+		params    queryParams
+		parameter string
+	}{
+		{queryParams{country: "KH"}, "country"},
+		{queryParams{country: "SG", sortBy: "popularity"}, "sortBy"},
+	}
+	for _, table := range tables {
+		_, _, newsErr := getNewsForCountryResponse(table.params)
+		if apiErr := toAPIError(newsErr); newsErr == nil || apiErr.status != http.StatusBadRequest || apiErr.Parameter != table.parameter {
+			t.Errorf("newsErr should be about the %s parameter for %+v, got: %v.", table.parameter, table.params, newsErr)
+		}
+	}
+}
+
+func TestGetNewsForCountryResponse_NotAvailable(t *testing.T) {
+	defer func() { isNewsAvailable = news.IsAvailable }()
+	isNewsAvailable = func() bool { return false }
+	_, _, newsErr := getNewsForCountryResponse(queryParams{country: "SG"})
+	if apiErr := toAPIError(newsErr); newsErr == nil || apiErr.status != http.StatusServiceUnavailable || apiErr.Code != codeNewsUnavailable {
+		t.Errorf("newsErr should be that news is not available, got: %v.", newsErr)
+	}
+}
+
+func TestGetStatusResponse(t *testing.T) {
+	defer func() {
+		getLastDate, isNewsAvailable, getNewsProviders = casecount.GetLastDate, news.IsAvailable, news.GetProviderNames
+	}()
+	tables := []struct {
+		loaded        bool
+		newsAvailable bool
+		providers     []string
+		expected      string
+	}{
+		{true, true, []string{"News API"}, `{"caseCounts":{"loaded":true,"lastDate":"3/31/20"},"news":{"available":true,"providers":["News API"]}}`},
+		{false, false, []string{}, `{"caseCounts":{"loaded":false},"news":{"available":false,"providers":[]}}`},
+	}
+	for _, table := range tables {
+		loaded, newsAvailable, providers := table.loaded, table.newsAvailable, table.providers
+		getLastDate = func() (time.Time, bool) {
+			return time.Date(2020, 3, 31, 0, 0, 0, 0, time.UTC), loaded
+		}
+		isNewsAvailable = func() bool { return newsAvailable }
+		getNewsProviders = func() []string { return providers }
+		response, err, statusErr := getStatusResponse(queryParams{})
+		if err != nil || statusErr != nil || string(response) != table.expected {
+			t.Errorf("Status is incorrect, got: %s %v %v, want: %s.", response, err, statusErr, table.expected)
+		}
+	}
+}
+
+func TestGetTimeline(t *testing.T) {
+	var country casecount.Country
+	countryJSON := `{"country":"Singapore","lat":1.28,"long":103.83,"population":5850342,"counts":[{"date":"2020-03-01","confirmed":106,"deaths":0,"recovered":72},{"date":"2020-03-02","confirmed":108,"deaths":0,"recovered":78}]}`
+	if err := json.Unmarshal([]byte(countryJSON), &country); err != nil {
+		t.Fatalf("Case counts could not be parsed: %s.", err.Error())
+	}
+	articles := []news.Article{
+		news.Article{Title: "Late on the second", URL: "url1", PublishedAt: "2020-03-02T23:00:00Z"},
+		news.Article{Title: "Early on the third in Singapore", URL: "url2", PublishedAt: "2020-03-03T06:00:00+08:00"},
+		news.Article{Title: "First", URL: "url3", PublishedAt: "2020-03-01T10:00:00Z"},
+		news.Article{Title: "Before the counts", URL: "url4", PublishedAt: "2020-02-29T10:00:00Z"},
+		news.Article{Title: "Undated", URL: "url5"},
+	}
+	timeline := getTimeline(country, articles, nil, dateformat.FormatISO)
+	if timeline.Name != "Singapore" || timeline.Population != 5850342 || len(timeline.Days) != 2 {
+		t.Fatalf("Timeline is incorrect, got: %+v.", timeline)
+	}
+	expected := [][]string{[]string{"url3"}, []string{"url1", "url2"}}
+	for i, day := range timeline.Days {
+		urls := make([]string, len(day.Articles))
+		for j, article := range day.Articles {
+			urls[j] = article.URL
+		}
+		if day.Date != country.Counts[i].Date || strings.Join(urls, ",") != strings.Join(expected[i], ",") {
+			t.Errorf("Day %d of the timeline is incorrect, got: %s %v, want: %s %v.", i, day.Date, urls, country.Counts[i].Date, expected[i])
+		}
+	}
+	response, err := json.Marshal(getTimeline(country, nil, nil, dateformat.FormatISO))
+	expectedJSON := `{"country":"Singapore","lat":1.28,"long":103.83,"population":5850342,"days":[{"date":"2020-03-01","confirmed":106,"deaths":0,"recovered":72,"articles":[]},{"date":"2020-03-02","confirmed":108,"deaths":0,"recovered":78,"articles":[]}]}`
+	if err != nil || string(response) != expectedJSON {
+		t.Errorf("Timeline JSON is incorrect, got: %s %v, want: %s.", response, err, expectedJSON)
+	}
+	response, err = json.Marshal(getTimeline(country, nil, toNewsError(errors.New("connection refused")), dateformat.FormatISO))
+	expectedJSON = `{"country":"Singapore","lat":1.28,"long":103.83,"population":5850342,"days":[{"date":"2020-03-01","confirmed":106,"deaths":0,"recovered":72,"articles":[]},{"date":"2020-03-02","confirmed":108,"deaths":0,"recovered":78,"articles":[]}],"newsError":{"code":"upstream_error","message":"connection refused"}}`
+	if err != nil || string(response) != expectedJSON {
+		t.Errorf("Timeline JSON with a news error is incorrect, got: %s %v, want: %s.", response, err, expectedJSON)
+	}
+}
+
+func TestGetTimelineResponse_InvalidQuery(t *testing.T) {
+	tables := []struct {
+		params    queryParams
+		parameter string
+	}{
+		{queryParams{}, "country"},
+	}
+	for _, table := range tables {
+		_, _, timelineErr := getTimelineResponse(table.params)
+		if apiErr := toAPIError(timelineErr); timelineErr == nil || apiErr.status != http.StatusBadRequest || apiErr.Parameter != table.parameter {
+			t.Errorf("timelineErr should be about the %s parameter for %+v, got: %v.", table.parameter, table.params, timelineErr)
+		}
+	}
+}
+
+func TestEndpoint_ParseValues(t *testing.T) {
+	tables := []struct {
+		rawurl    string
+		endpoint  endpoint
+		expected  queryValues
+		parameter string
+	}{
+		{"http://localhost:8080/cases?Interval=EpiWeek&state=a&STATE=b&q=ignored", casesEndpoint,
+			queryValues{"interval": []string{"epiweek"}, "state": []string{"a", "b"}, "dateformat": []string{"jhu"}}, ""},
+		{"http://localhost:8080/news?sortBy=POPULARITY&pageSize=5&state=ignored", newsEndpoint,
+			queryValues{"sortby": []string{"popularity"}, "pagesize": []string{"5"}, "page": []string{"1"}, "language": []string{"en"}}, ""},
+		{"http://localhost:8080/news?language=", newsEndpoint,
+			queryValues{"pagesize": []string{"20"}, "page": []string{"1"}, "language": []string{"en"}}, ""},
+		{"http://localhost:8080/status?strict=true&dateFormat=iso", statusEndpoint, queryValues{"dateformat": []string{"iso"}, "strict": []string{"true"}}, ""},
+		{"http://localhost:8080/status?strict=true&perDay=true&country=sg", statusEndpoint, nil, "country"},
+		{"http://localhost:8080/timeline?strict=true&country=sg&interval=week", timelineEndpoint, nil, "interval"},
+		{"http://localhost:8080/news?strict=1&state=sg", newsEndpoint, nil, "state"},
+		{"http://localhost:8080/news?pageSize=0", newsEndpoint, nil, "pageSize"},
+		{"http://localhost:8080/cases?interval=fortnight", casesEndpoint, nil, "interval"},
+	}
+	for _, table := range tables {
+		inputURL, _ := url.Parse(table.rawurl)
+		values, err := table.endpoint.parseValues(inputURL)
+		if table.parameter != "" {
+			if err == nil || toAPIError(err).Parameter != table.parameter {
+				t.Errorf("Error should be about the %s parameter for %s, got: %v.", table.parameter, table.rawurl, err)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(values, table.expected) {
+			t.Errorf("Values are incorrect for %s, got: %v %v, want: %v.", table.rawurl, values, err, table.expected)
+		}
+	}
+}
+
+func TestGetDocsResponse(t *testing.T) {
+	response, err, docsErr := getDocsResponse(queryParams{})
+	if err != nil || docsErr != nil {
+		t.Fatalf("Errors should be nil, got: %v, %v.", err, docsErr)
+	}
+	var docs []struct {
+		Path       string
+		Parameters []struct {
+			Name    string
+			Default string
+			Allowed []string
+		}
+	}
+	if err := json.Unmarshal(response, &docs); err != nil || len(docs) != len(endpoints) {
+		t.Fatalf("Docs should have every endpoint, got: %s %v.", response, err)
+	}
+	for _, doc := range docs {
+		if len(doc.Parameters) == 0 || doc.Parameters[len(doc.Parameters)-1].Name != "strict" {
+			t.Errorf("Every endpoint should accept strict, got: %+v.", doc)
+		}
+		if doc.Path == "/news" && !strings.Contains(string(response), `"name":"pageSize","type":"integer","description":"Number of articles in each page","default":"20","min":1,"max":100`) {
+			t.Errorf("Docs of /news should have the page size with its default and range, got: %s.", response)
+		}
+	}
 }
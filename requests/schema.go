@@ -0,0 +1,241 @@
+package requests
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"yet-another-covid-map-api/casecount"
+	"yet-another-covid-map-api/dateformat"
+	"yet-another-covid-map-api/news"
+)
+
+// paramType : how the value of a query parameter is parsed
+type paramType string
+
+const (
+	stringParam paramType = "string"
+	// boolParam : true if the value is any of the true values of strconv.ParseBool, and false otherwise
+	boolParam paramType = "boolean"
+	// intParam : a whole number between the min and max of the parameter, where a max of 0 means there is no max
+	intParam paramType = "integer"
+	// dateParam : a date in any of the input date formats or relative to the latest date, which is formatted in the date layout of the endpoint
+	dateParam paramType = "date"
+	// listParam : a string that can be given more than once, keeping every value in the order they were given
+	listParam paramType = "list"
+)
+
+// parameter : a query parameter of an endpoint, whose name is matched ignoring case. Values that are given must be one of the allowed values
+// if there are any, which are matched ignoring case, and the default is used when the parameter is not given. Label names the parameter in errors
+type parameter struct {
+	Name        string    `json:"name"`
+	Type        paramType `json:"type"`
+	Description string    `json:"description"`
+	Default     string    `json:"default,omitempty"`
+	Allowed     []string  `json:"allowed,omitempty"`
+	Min         int       `json:"min,omitempty"`
+	Max         int       `json:"max,omitempty"`
+	label       string
+}
+
+// endpoint : the path of an endpoint and the query parameters that it accepts. Dates in queries are formatted in dateLayout before they are used,
+// and relative dates and last count back from today instead of the latest case count date when datesFromToday is set
+type endpoint struct {
+	Path           string      `json:"path"`
+	Description    string      `json:"description"`
+	Parameters     []parameter `json:"parameters"`
+	dateLayout     string
+	datesFromToday bool
+}
+
+// queryValues : the values of the parameters of an endpoint that were given or have a default, by lower case name
+type queryValues map[string][]string
+
+var (
+	fromParameter = parameter{Name: "from", Type: dateParam, label: "From",
+		Description: "First date of the query, such as 3/1/20, 2020-03-01 or latest-1w"}
+	toParameter = parameter{Name: "to", Type: dateParam, label: "To",
+		Description: "Last date of the query, such as 3/31/20, 2020-03-31 or latest"}
+	lastParameter = parameter{Name: "last", Type: intParam, Min: 1, label: "Last",
+		Description: "Number of days up to the latest date or to the 'to' date, instead of 'from'"}
+	inputDateFormatParameter = parameter{Name: "inputDateFormat", Type: stringParam, Allowed: dateformat.InputFormats, label: "Input date format",
+		Description: "Format of 'from' and 'to', which is detected from the dates if it is not given, with month first dates preferred"}
+	dateFormatParameter = parameter{Name: "dateFormat", Type: stringParam, Default: dateformat.FormatJHU, Allowed: dateformat.OutputFormats, label: "Date format",
+		Description: "Format of the dates in the response"}
+	countryParameter = parameter{Name: "country", Type: stringParam, label: "Country",
+		Description: "Name, ISO 3166 Alpha-2 or Alpha-3 code of a country"}
+	// countryPathParameter : the country in the path of /countries/{country}, which replaces a country given in the query
+	countryPathParameter = parameter{Name: "country", Type: stringParam, label: "Country",
+		Description: "Name, ISO 3166 Alpha-2 or Alpha-3 code of a country, which is given in the path"}
+	stateParameter = parameter{Name: "state", Type: listParam, label: "State",
+		Description: "Name, FIPS code or UID of a state, which can be given more than once for states in the same country"}
+	fipsParameter = parameter{Name: "fips", Type: stringParam, label: "FIPS",
+		Description: "FIPS code of a US state, instead of 'state'"}
+	aggregateCountriesParameter = parameter{Name: "aggregateCountries", Type: boolParam, label: "Aggregate countries",
+		Description: "Whether to add up the states of each country"}
+	perDayParameter = parameter{Name: "perDay", Type: boolParam, label: "Per day",
+		Description: "Whether to return the counts of each day instead of the totals between the dates"}
+	worldTotalParameter = parameter{Name: "worldTotal", Type: boolParam, label: "World total",
+		Description: "Whether to return the counts of the whole world"}
+	intervalParameter = parameter{Name: "interval", Type: stringParam, Allowed: casecount.Intervals, label: "Interval",
+		Description: "Period that each count covers with perDay or worldTotal, which is a day if it is not given"}
+	keywordsParameter = parameter{Name: "q", Type: stringParam, label: "Query",
+		Description: "Keywords that the news is searched for, which are coronavirus keywords if they are not given"}
+	languageParameter = parameter{Name: "language", Type: stringParam, Default: news.DefaultLanguage, Allowed: news.Languages, label: "Language",
+		Description: "Language of the news"}
+	sortByParameter = parameter{Name: "sortBy", Type: stringParam, Allowed: news.SortOptions, label: "Sort option",
+		Description: "Order of the news from every country, which cannot be given together with 'country'"}
+	pageParameter = parameter{Name: "page", Type: intParam, Default: "1", Min: 1, label: "Page",
+		Description: "Page of the news, starting from 1"}
+	pageSizeParameter = parameter{Name: "pageSize", Type: intParam, Default: strconv.Itoa(news.DefaultPageSize), Min: 1, Max: news.MaxPageSize, label: "Page size",
+		Description: "Number of articles in each page"}
+	autocompleteQueryParameter = parameter{Name: "q", Type: stringParam, label: "Query",
+		Description: "Start of the name or code of a country, state or US county"}
+	limitParameter = parameter{Name: "limit", Type: intParam, Default: strconv.Itoa(defaultAutocompleteLimit), Min: 1, Max: maxAutocompleteLimit, label: "Limit",
+		Description: "Most matches that are returned"}
+	// strictParameter : accepted by every endpoint, rejects the query if it has any parameter that the endpoint does not accept instead of ignoring it
+	strictParameter = parameter{Name: "strict", Type: boolParam, label: "Strict",
+		Description: "Whether to reject the query if it has parameters that the endpoint does not accept, instead of ignoring them"}
+)
+
+var (
+	casesEndpoint = newEndpoint("/cases", "Case counts of every country and state, or of the country and states in the query", dateformat.CasesDateFormat,
+		fromParameter, toParameter, lastParameter, inputDateFormatParameter, dateFormatParameter, countryParameter, stateParameter, fipsParameter,
+		aggregateCountriesParameter, perDayParameter, worldTotalParameter, intervalParameter)
+	newsEndpoint = newEndpoint("/news", "A page of the coronavirus news, from every country or from the country in the query", dateformat.NewsDateFormat,
+		fromParameter, toParameter, lastParameter, inputDateFormatParameter, countryParameter, keywordsParameter, languageParameter, sortByParameter,
+		pageParameter, pageSizeParameter).countingFromToday()
+	countriesEndpoint = newEndpoint("/countries", "Codes, location, population and range of dates with data of every country and its states", dateformat.CasesDateFormat,
+		countryParameter, dateFormatParameter)
+	countryEndpoint = newEndpoint(countriesPathPrefix+"{country}", "The same as /countries for only the country in the path", dateformat.CasesDateFormat,
+		countryPathParameter, dateFormatParameter)
+	autocompleteEndpoint = newEndpoint("/autocomplete", "Countries, states and US counties that complete the query, ranked from the best match", dateformat.CasesDateFormat,
+		autocompleteQueryParameter, limitParameter)
+	timelineEndpoint = newEndpoint("/timeline", "Case counts of the country for each day, with the news published on each day", dateformat.CasesDateFormat,
+		fromParameter, toParameter, lastParameter, inputDateFormatParameter, dateFormatParameter, countryParameter, keywordsParameter, languageParameter)
+	statusEndpoint = newEndpoint("/status", "Whether the case counts have been loaded and whether news is available", dateformat.CasesDateFormat,
+		dateFormatParameter)
+	docsEndpoint = newEndpoint("/docs", "The endpoints of the API and their query parameters", dateformat.CasesDateFormat)
+)
+
+// endpoints : every endpoint in the order they are documented
+var endpoints = []endpoint{casesEndpoint, newsEndpoint, countriesEndpoint, countryEndpoint, autocompleteEndpoint, timelineEndpoint, statusEndpoint, docsEndpoint}
+
+// newEndpoint : endpoint that accepts the parameters and strict
+func newEndpoint(path string, description string, dateLayout string, parameters ...parameter) endpoint {
+	return endpoint{path, description, append(parameters, strictParameter), dateLayout, false}
+}
+
+// countingFromToday : the endpoint with relative dates and last counting back from today, for dates that are not case count dates
+func (e endpoint) countingFromToday() endpoint {
+	e.datesFromToday = true
+	return e
+}
+
+// getParameterNames : names of the parameters that the endpoint accepts
+func (e endpoint) getParameterNames() []string {
+	names := make([]string, len(e.Parameters))
+	for i, p := range e.Parameters {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// getParameter : the parameter of the endpoint with the name, ignoring case
+func (e endpoint) getParameter(name string) (parameter, bool) {
+	for _, p := range e.Parameters {
+		if strings.EqualFold(p.Name, name) {
+			return p, true
+		}
+	}
+	return parameter{}, false
+}
+
+// parseValues : get the values of the parameters of the endpoint, with the defaults of the ones that were not given. Parameters that the endpoint
+// does not accept are ignored, unless strict is true in which case the first of them in alphabetical order is an error
+func (e endpoint) parseValues(URL *url.URL) (queryValues, error) {
+	if isStringTrue(parseURLQuery(URL, strings.ToLower(strictParameter.Name))) {
+		keys := make([]string, 0, len(URL.Query()))
+		for key := range URL.Query() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if _, ok := e.getParameter(key); !ok {
+				return nil, newInvalidParameterError(key, fmt.Errorf("Parameter %s is not accepted by %s, please use only: %s", key, e.Path, strings.Join(e.getParameterNames(), ", ")))
+			}
+		}
+	}
+	values := make(queryValues)
+	for _, p := range e.Parameters {
+		name := strings.ToLower(p.Name)
+		var given []string
+		if p.Type == listParam {
+			given = parseURLQueryValues(URL, name)
+		} else if value := parseURLQuery(URL, name); value != "" {
+			given = []string{value}
+		}
+		if len(given) == 0 {
+			if p.Default != "" {
+				values[name] = []string{p.Default}
+			}
+			continue
+		}
+		for i, value := range given {
+			validValue, err := p.validate(value)
+			if err != nil {
+				return nil, err
+			}
+			given[i] = validValue
+		}
+		values[name] = given
+	}
+	return values, nil
+}
+
+// validate : get the value in the case of the allowed value that it matches, or an error if it is not allowed or is a number out of range
+func (p parameter) validate(value string) (string, error) {
+	if len(p.Allowed) > 0 {
+		for _, allowed := range p.Allowed {
+			if strings.EqualFold(value, allowed) {
+				return allowed, nil
+			}
+		}
+		return "", newInvalidParameterError(p.Name, fmt.Errorf("%s %s is not recognised, please use one of: %s", p.label, value, strings.Join(p.Allowed, ", ")))
+	}
+	if p.Type == intParam {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < p.Min || (p.Max > 0 && number > p.Max) {
+			if p.Max > 0 {
+				return "", newInvalidParameterError(p.Name, fmt.Errorf("%s %s is not valid, please use a number from %d to %d", p.label, value, p.Min, p.Max))
+			}
+			return "", newInvalidParameterError(p.Name, fmt.Errorf("%s %s is not valid, please use a number of at least %d", p.label, value, p.Min))
+		}
+	}
+	return value, nil
+}
+
+// get : the value of the parameter, or the first value if it was given more than once
+func (v queryValues) get(name string) string {
+	if values := v[strings.ToLower(name)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// getList : every value of the parameter in the order they were given
+func (v queryValues) getList(name string) []string {
+	return v[strings.ToLower(name)]
+}
+
+// getInt : the value of the parameter as a number, which has already been validated, or 0 if it was not given
+func (v queryValues) getInt(name string) int {
+	number, _ := strconv.Atoi(v.get(name))
+	return number
+}
+
+// getBool : whether the value of the parameter is true
+func (v queryValues) getBool(name string) bool {
+	return isStringTrue(v.get(name))
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// LocationTypeCountry : type of a country in autocomplete matches
+	LocationTypeCountry = "country"
+	// LocationTypeState : type of a state or province of a country in autocomplete matches
+	LocationTypeState = "state"
+	// LocationTypeCounty : type of a county of a US state in autocomplete matches
+	LocationTypeCounty = "county"
+)
+
+// locationTypeOrder : rank of each type of location when matches have the same score, so that larger locations come first
+var locationTypeOrder = map[string]int{LocationTypeCountry: 0, LocationTypeState: 1, LocationTypeCounty: 2}
+
+// LocationMatch : a country, state or county that matches an autocomplete query, with the country and state that it is in,
+// and a score from 0 to 1 where 1 is an exact match. Names that start with the query score above 0.5
+type LocationMatch struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	UID        string  `json:"uid"`
+	ISO2       string  `json:"iso2,omitempty"`
+	ISO3       string  `json:"iso3,omitempty"`
+	FIPS       string  `json:"fips,omitempty"`
+	State      string  `json:"state,omitempty"`
+	Country    string  `json:"country,omitempty"`
+	CountryISO string  `json:"countryIso,omitempty"`
+	Score      float64 `json:"score"`
+	population int
+}
+
+// toLocationMatch : get the match of the location with the UID in the autocomplete results
+func (l *LocationLookup) toLocationMatch(locationType string, match fuzzyMatch) LocationMatch {
+	location := l.byUID[match.value]
+	result := LocationMatch{locationType, "", location.UID, location.ISO2, location.ISO3, location.FIPS, "", "", "",
+		math.Round(match.score*1000) / 1000, location.Population}
+	switch locationType {
+	case LocationTypeCountry:
+		result.Name = location.Country
+	case LocationTypeState:
+		result.Name, result.Country = location.State, location.Country
+		result.CountryISO, _ = l.GetCountryISO(*location)
+	case LocationTypeCounty:
+		result.Name, result.State, result.Country = location.County, location.State, location.Country
+		result.CountryISO, _ = l.GetCountryISO(*location)
+	}
+	return result
+}
+
+// Autocomplete : get up to limit countries, states and US counties with a name or code that starts with query, or that is a few typos away from it.
+// Matches are ranked by score, then countries before states before counties, then by population
+func (l *LocationLookup) Autocomplete(query string, limit int) []LocationMatch {
+	if limit <= 0 {
+		return nil
+	}
+	var matches []LocationMatch
+	for _, index := range []struct {
+		locationType string
+		index        *fuzzyIndex
+	}{
+		{LocationTypeCountry, l.countryIndex},
+		{LocationTypeState, l.stateIndex},
+		{LocationTypeCounty, l.countyIndex},
+	} {
+		for _, match := range index.index.complete(query, nil) {
+			matches = append(matches, l.toLocationMatch(index.locationType, match))
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		if matches[i].Type != matches[j].Type {
+			return locationTypeOrder[matches[i].Type] < locationTypeOrder[matches[j].Type]
+		}
+		if matches[i].population != matches[j].population {
+			return matches[i].population > matches[j].population
+		}
+		return matches[i].UID < matches[j].UID
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
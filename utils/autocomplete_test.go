@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestLocationLookup_Autocomplete(t *testing.T) {
+	lookup := getTestLocationLookup()
+	tables := []struct {
+		query    string
+		limit    int
+		expected []string
+	}{
+		{"sing", 10, []string{"country:702"}},
+		{"SGP", 10, []string{"country:702"}},
+		{"usa", 10, []string{"country:840"}},
+		{"new", 10, []string{"state:84000036", "county:84036061"}},
+		{"new", 1, []string{"state:84000036"}},
+		{"diamond", 10, []string{"state:12490", "state:84088888"}},
+		{"Quebec", 10, []string{"state:12411"}},
+		{"Nw York", 10, []string{"state:84000036", "county:84036061"}},
+		{"c", 10, []string{"country:156", "country:124"}},
+		{"", 10, []string{}},
+		{"sing", 0, []string{}},
+	}
+	for _, table := range tables {
+		matches := lookup.Autocomplete(table.query, table.limit)
+		if len(matches) != len(table.expected) {
+			t.Errorf("Number of matches is incorrect for %s, got: %+v, want: %v.", table.query, matches, table.expected)
+			continue
+		}
+		for i, match := range matches {
+			if result := match.Type + ":" + match.UID; result != table.expected[i] {
+				t.Errorf("Match %d is incorrect for %s, got: %s, want: %s.", i, table.query, result, table.expected[i])
+			}
+		}
+	}
+}
+
+func TestLocationLookup_AutocompleteParents(t *testing.T) {
+	lookup := getTestLocationLookup()
+	matches := lookup.Autocomplete("new york", 10)
+	expected := []LocationMatch{
+		LocationMatch{LocationTypeState, "New York", "84000036", "US", "USA", "36", "", "US", "US", 1, 19453561},
+		LocationMatch{LocationTypeCounty, "New York", "84036061", "US", "USA", "36061", "New York", "US", "US", 1, 1628706},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("Number of matches is incorrect, got: %+v, want: %+v.", matches, expected)
+	}
+	for i, match := range matches {
+		if match != expected[i] {
+			t.Errorf("Match %d is incorrect, got: %+v, want: %+v.", i, match, expected[i])
+		}
+	}
+	if matches := lookup.Autocomplete("puerto", 10); len(matches) != 1 || matches[0].ISO2 != "PR" || matches[0].CountryISO != "US" {
+		t.Errorf("Puerto Rico should be a state of the US with its own iso2, got: %+v.", matches)
+	}
+	if matches := lookup.Autocomplete("sngapore", 10); len(matches) != 1 || matches[0].Score >= 0.5 {
+		t.Errorf("Matches with typos should score below 0.5, got: %+v.", matches)
+	}
+}
@@ -35,6 +35,7 @@ func getLookupData() {
 	}
 	populateAbbreviationCountryMaps(data[1:])
 	populatePopulationMaps(data[1:])
+	Locations = NewLocationLookup(data[1:])
 }
 
 func populateAbbreviationCountryMaps(data [][]string) {
@@ -79,29 +80,14 @@ func GetCountryFromAbbreviation(abbr string) (string, bool) {
 	return "", false
 }
 
-// GetAbbreviationFromCountry : get iso code from country name
+// GetAbbreviationFromCountry : get iso code from country name, ISO 3166 alpha-2, alpha-3 or numeric code, UID or common alias such as USA or Burma.
+// If the country is not found, the name of the closest match is returned instead
 func GetAbbreviationFromCountry(country string) (string, bool) {
-	if _, ok := AbbreviationToCountry[strings.ToUpper(country)]; ok {
-		// input is already an iso
-		return strings.ToUpper(country), true
-	}
-	if abbr, ok := CountryToAbbreviation[country]; ok {
+	if abbr, ok := Locations.FindCountry(country); ok {
 		return abbr, true
 	}
-	return lowerCaseCountryLookup(country)
-}
-
-func lowerCaseCountryLookup(country string) (string, bool) {
-	minEditDistance := -1
-	closestMatch := ""
-	for countryKey, iso := range CountryToAbbreviation {
-		if strings.ToLower(countryKey) == strings.ToLower(country) {
-			return iso, true
-		}
-		if editDistance := editDistance([]rune(country), []rune(countryKey)); minEditDistance == -1 || editDistance < minEditDistance {
-			minEditDistance = editDistance
-			closestMatch = countryKey
-		}
+	if suggestions := Locations.SuggestCountries(country, 1); len(suggestions) > 0 {
+		return suggestions[0].Country, false
 	}
-	return closestMatch, false
+	return "", false
 }
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// CountrySuggestion : a known country that is similar to a country that was not found, with a score from 0 to 1 where 1 is an exact match
+type CountrySuggestion struct {
+	ISO     string  `json:"iso"`
+	Country string  `json:"country"`
+	Score   float64 `json:"score"`
+}
+
+// countryAliases : common names of countries that are different from the names used by John Hopkins CSSE
+var countryAliases = map[string][]string{
+	"US": []string{"USA", "United States", "United States of America", "America"},
+	"GB": []string{"UK", "Britain", "Great Britain", "England"},
+	"KR": []string{"South Korea", "Korea", "Republic of Korea"},
+	"MM": []string{"Burma"},
+	"CZ": []string{"Czech Republic"},
+	"TW": []string{"Taiwan"},
+	"CI": []string{"Ivory Coast"},
+	"CV": []string{"Cape Verde"},
+	"VA": []string{"Vatican", "Vatican City"},
+	"CD": []string{"DR Congo", "DRC", "Democratic Republic of the Congo"},
+	"CG": []string{"Republic of the Congo"},
+	"MK": []string{"Macedonia"},
+	"SZ": []string{"Swaziland"},
+	"TL": []string{"East Timor"},
+	"PS": []string{"Palestine"},
+	"RU": []string{"Russian Federation"},
+	"AE": []string{"UAE"},
+	"LA": []string{"Laos"},
+}
+
+// diacritics : lower case letters with diacritics to the letters without them
+var diacritics = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a", 'æ': "ae",
+	'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e", 'ğ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'ş': "s", 'š': "s", 'ß': "ss", 'ţ': "t", 'ť': "t",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// normalizeName : lower case the name of a country or state, strip diacritics and punctuation and collapse whitespace, so that Côte d'Ivoire matches cote divoire
+func normalizeName(name string) string {
+	var builder strings.Builder
+	builder.Grow(len(name))
+	space := false
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && builder.Len() > 0 {
+				builder.WriteRune(' ')
+			}
+			r = unicode.ToLower(r)
+			if replacement, ok := diacritics[r]; r >= utf8.RuneSelf && ok {
+				builder.WriteString(replacement)
+			} else {
+				builder.WriteRune(r)
+			}
+			space = false
+		} else if unicode.IsSpace(r) || r == '-' || r == ',' {
+			space = true
+		}
+	}
+	return builder.String()
+}
+
+// getSimilarity : score from 0 to 1 of how similar query is to term, where a query that starts term scores at least 0.5
+func getSimilarity(query string, term string) float64 {
+	queryRunes, termRunes := []rune(query), []rune(term)
+	maxLen := len(queryRunes)
+	if len(termRunes) > maxLen {
+		maxLen = len(termRunes)
+	}
+	if maxLen == 0 {
+		return 0
+	}
+	score := 1 - float64(editDistance(queryRunes, termRunes))/float64(maxLen)
+	if len(queryRunes) >= 3 && strings.HasPrefix(term, query) {
+		score = math.Max(score, 0.5+0.5*float64(len(queryRunes))/float64(len(termRunes)))
+	}
+	return score
+}
+
+// SuggestCountries : get up to k known countries that are the most similar to country, comparing against the names,
+// ISO 3166 alpha-2 and alpha-3 codes and common aliases of every country with diacritics stripped. Only countries within a few
+// edits of country or starting with it are suggested
+func (l *LocationLookup) SuggestCountries(country string, k int) []CountrySuggestion {
+	if k <= 0 {
+		return nil
+	}
+	matches := l.countryIndex.search(country, nil)
+	suggestions := make([]CountrySuggestion, 0, len(matches))
+	for _, match := range matches {
+		location := l.byUID[match.value]
+		suggestions = append(suggestions, CountrySuggestion{location.ISO2, location.Country, math.Round(match.score*1000) / 1000})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Country < suggestions[j].Country
+	})
+	if len(suggestions) > k {
+		suggestions = suggestions[:k]
+	}
+	return suggestions
+}
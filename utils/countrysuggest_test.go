@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"testing"
+)
+
+func setTestCountryLookup() {
+	AbbreviationToCountry = map[string]string{
+		"US": "US",
+		"GB": "United Kingdom",
+		"KR": "Korea, South",
+		"MM": "Burma",
+		"CI": "Cote d'Ivoire",
+		"SG": "Singapore",
+		"SE": "Sweden",
+		"CH": "Switzerland",
+		"AE": "United Arab Emirates",
+		"TW": "Taiwan*",
+	}
+	CountryToAbbreviation = make(map[string]string)
+	for iso, country := range AbbreviationToCountry {
+		CountryToAbbreviation[country] = iso
+	}
+	Locations = NewLocationLookup([][]string{
+		[]string{"840", "US", "USA", "840", "", "", "", "US", "40", "-100", "US", "329466283"},
+		[]string{"826", "GB", "GBR", "826", "", "", "", "United Kingdom", "55.3781", "-3.436", "United Kingdom", "67886004"},
+		[]string{"410", "KR", "KOR", "410", "", "", "", "Korea, South", "35.907757", "127.766922", "Korea, South", "51269183"},
+		[]string{"104", "MM", "MMR", "104", "", "", "", "Burma", "21.9162", "95.956", "Burma", "54409794"},
+		[]string{"384", "CI", "CIV", "384", "", "", "", "Cote d'Ivoire", "7.54", "-5.5471", "Cote d'Ivoire", "26378275"},
+		[]string{"702", "SG", "SGP", "702", "", "", "", "Singapore", "1.2833", "103.8333", "Singapore", "5850343"},
+		[]string{"752", "SE", "SWE", "752", "", "", "", "Sweden", "60.128161", "18.643501", "Sweden", "10099270"},
+		[]string{"756", "CH", "CHE", "756", "", "", "", "Switzerland", "46.8182", "8.2275", "Switzerland", "8654618"},
+		[]string{"784", "AE", "ARE", "784", "", "", "", "United Arab Emirates", "23.424076", "53.847818", "United Arab Emirates", "9890400"},
+		[]string{"158", "TW", "TWN", "158", "", "", "", "Taiwan*", "23.7", "121", "Taiwan*", "23816775"},
+	})
+}
+
+func TestNormalizeCountry(t *testing.T) {
+	tables := []struct {
+		country  string
+		expected string
+	}{
+		{"Côte d'Ivoire", "cote divoire"},
+		{"  Korea,  South ", "korea south"},
+		{"Taiwan*", "taiwan"},
+		{"Guinea-Bissau", "guinea bissau"},
+		{"São Tomé and Príncipe", "sao tome and principe"},
+		{"", ""},
+	}
+	for _, table := range tables {
+		if result := normalizeName(table.country); result != table.expected {
+			t.Errorf("Normalized country is incorrect for %s, got: %s, want: %s.", table.country, result, table.expected)
+		}
+	}
+}
+
+func TestGetAbbreviationFromCountry_AliasesAndCodes(t *testing.T) {
+	setTestCountryLookup()
+	tables := []struct {
+		country string
+		iso     string
+		ok      bool
+	}{
+		{"USA", "US", true},
+		{"United States", "US", true},
+		{"uk", "GB", true},
+		{"GBR", "GB", true},
+		{"South Korea", "KR", true},
+		{"korea south", "KR", true},
+		{"Burma", "MM", true},
+		{"Myanmar", "", false},
+		{"Côte d'Ivoire", "CI", true},
+		{"Ivory Coast", "CI", true},
+		{"taiwan", "TW", true},
+		{"Sngapore", "Singapore", false},
+	}
+	for _, table := range tables {
+		iso, ok := GetAbbreviationFromCountry(table.country)
+		if ok != table.ok {
+			t.Errorf("ok is incorrect for %s, got: %t, want: %t.", table.country, ok, table.ok)
+		}
+		if table.ok && iso != table.iso {
+			t.Errorf("iso is incorrect for %s, got: %s, want: %s.", table.country, iso, table.iso)
+		}
+		if !table.ok && table.iso != "" && iso != table.iso {
+			t.Errorf("closest match is incorrect for %s, got: %s, want: %s.", table.country, iso, table.iso)
+		}
+	}
+}
+
+func TestSuggestCountries(t *testing.T) {
+	setTestCountryLookup()
+	tables := []struct {
+		country  string
+		k        int
+		expected []string
+	}{
+		{"Sngapore", 1, []string{"SG"}},
+		{"Swedn", 1, []string{"SE"}},
+		{"united", 3, []string{"US", "GB", "AE"}},
+		{"Cote dIvoir", 1, []string{"CI"}},
+		{"SGR", 1, []string{"SG"}},
+		{"", 3, []string{}},
+		{"Sweden", 0, []string{}},
+	}
+	for _, table := range tables {
+		suggestions := Locations.SuggestCountries(table.country, table.k)
+		if len(suggestions) != len(table.expected) {
+			t.Errorf("Number of suggestions is incorrect for %s, got: %+v, want: %v.", table.country, suggestions, table.expected)
+			continue
+		}
+		for i, suggestion := range suggestions {
+			if suggestion.ISO != table.expected[i] {
+				t.Errorf("Suggestion %d is incorrect for %s, got: %+v, want: %s.", i, table.country, suggestion, table.expected[i])
+			}
+			if i > 0 && suggestion.Score > suggestions[i-1].Score {
+				t.Errorf("Suggestions are not ranked by score for %s, got: %+v.", table.country, suggestions)
+			}
+		}
+	}
+}
+
+func TestSuggestCountries_ExactAliasScoresOne(t *testing.T) {
+	setTestCountryLookup()
+	suggestions := Locations.SuggestCountries("Burma", 1)
+	if len(suggestions) != 1 || suggestions[0].ISO != "MM" || suggestions[0].Score != 1 {
+		t.Errorf("Suggestion is incorrect, got: %+v, want: %+v.", suggestions, CountrySuggestion{"MM", "Burma", 1})
+	}
+}
@@ -4,7 +4,14 @@ package utils
 func editDistance(str1, str2 []rune) int {
 	s1len := len(str1)
 	s2len := len(str2)
-	column := make([]int, len(str1)+1)
+	// names are short, so the column usually fits in an array on the stack instead of being allocated for every comparison
+	var buffer [64]int
+	var column []int
+	if s1len < len(buffer) {
+		column = buffer[:s1len+1]
+	} else {
+		column = make([]int, s1len+1)
+	}
 
 	for y := 1; y <= s1len; y++ {
 		column[y] = y
@@ -38,3 +45,16 @@ func minimum(a, b, c int) int {
 	}
 	return c
 }
+
+// GetEditSimilarity : similarity from 0 to 1 of two strings by the number of edits between them relative to the longer one, where 1 means equal
+func GetEditSimilarity(str1 string, str2 string) float64 {
+	runes1, runes2 := []rune(str1), []rune(str2)
+	maxLen := len(runes1)
+	if len(runes2) > maxLen {
+		maxLen = len(runes2)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+	return 1 - float64(editDistance(runes1, runes2))/float64(maxLen)
+}
@@ -24,3 +24,24 @@ func TestEditDistance(t *testing.T) {
 		}
 	}
 }
+
+func TestGetEditSimilarity(t *testing.T) {
+	tables := []struct {
+		str1     string
+		str2     string
+		expected float64
+	}{
+		{"test", "test", 1},
+		{"test", "tast", 0.75},
+		{"testt", "tst", 0.6},
+		{"abcd", "", 0},
+		{"", "", 1},
+	}
+
+	for _, table := range tables {
+		result := GetEditSimilarity(table.str1, table.str2)
+		if result != table.expected {
+			t.Errorf("Result of GetEditSimilarity was incorrect for %s and %s, got: %f, want: %f.", table.str1, table.str2, result, table.expected)
+		}
+	}
+}
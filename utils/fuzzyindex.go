@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// minPrefixLength : queries with at least this many letters also match every name that starts with them
+const minPrefixLength = 3
+
+// fuzzyEntry : a name and the value that it identifies, such as the iso code of a country or the UID of a state
+type fuzzyEntry struct {
+	name  string
+	value string
+}
+
+// fuzzyMatch : a value with a name that is similar to a query, with a score from 0 to 1 where 1 is an exact match
+type fuzzyMatch struct {
+	value string
+	term  string
+	score float64
+}
+
+// bkNode : node of a BK-tree, where each child is keyed by its edit distance from the node
+type bkNode struct {
+	term     string
+	runes    []rune
+	children map[int]*bkNode
+}
+
+// fuzzyIndex : index of normalised names for exact, prefix and fuzzy lookups. Fuzzy lookups walk a BK-tree, so only the names
+// that can be within the maximum edit distance of the query are compared against it instead of every name
+type fuzzyIndex struct {
+	// values : normalised name to the values that have the name
+	values map[string][]string
+	// terms : sorted normalised names for prefix lookups
+	terms []string
+	root  *bkNode
+}
+
+// newFuzzyIndex : build the index of the entries, skipping the ones with an empty name or value
+func newFuzzyIndex(entries []fuzzyEntry) *fuzzyIndex {
+	index := &fuzzyIndex{values: make(map[string][]string, len(entries))}
+	for _, entry := range entries {
+		term := normalizeName(entry.name)
+		if term == "" || entry.value == "" {
+			continue
+		}
+		values, ok := index.values[term]
+		if !ok {
+			index.terms = append(index.terms, term)
+			index.insert(term)
+		}
+		if !containsValue(values, entry.value) {
+			index.values[term] = append(values, entry.value)
+		}
+	}
+	sort.Strings(index.terms)
+	return index
+}
+
+func containsValue(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// insert : add a normalised name that is not in the BK-tree yet
+func (f *fuzzyIndex) insert(term string) {
+	newNode := &bkNode{term, []rune(term), nil}
+	if f.root == nil {
+		f.root = newNode
+		return
+	}
+	node := f.root
+	for {
+		distance := editDistance(node.runes, newNode.runes)
+		child, ok := node.children[distance]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[int]*bkNode)
+			}
+			node.children[distance] = newNode
+			return
+		}
+		node = child
+	}
+}
+
+// get : get the values with a name that is equal to name after normalising both
+func (f *fuzzyIndex) get(name string) []string {
+	return f.values[normalizeName(name)]
+}
+
+// withinDistance : get the normalised names that are at most maxDistance edits away from term, which must be normalised.
+// By the triangle inequality, only the children of a node at a distance from it within maxDistance of the query's distance can match
+func (f *fuzzyIndex) withinDistance(term string, maxDistance int) []string {
+	if f.root == nil {
+		return nil
+	}
+	var terms []string
+	runes := []rune(term)
+	stack := []*bkNode{f.root}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		distance := editDistance(node.runes, runes)
+		if distance <= maxDistance {
+			terms = append(terms, node.term)
+		}
+		for childDistance, child := range node.children {
+			if childDistance >= distance-maxDistance && childDistance <= distance+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+	return terms
+}
+
+// withPrefix : get the normalised names that start with prefix, which must be normalised
+func (f *fuzzyIndex) withPrefix(prefix string) []string {
+	var terms []string
+	for i := sort.SearchStrings(f.terms, prefix); i < len(f.terms) && strings.HasPrefix(f.terms[i], prefix); i++ {
+		terms = append(terms, f.terms[i])
+	}
+	return terms
+}
+
+// getMaxDistance : number of edits allowed between a query and a matching name. It is at most 2, because the BK-tree
+// has to visit most of its nodes for larger distances
+func getMaxDistance(term string) int {
+	if utf8.RuneCountInString(term) <= 4 {
+		return 1
+	}
+	return 2
+}
+
+// search : get the values with a name that is within the maximum edit distance of query or that starts with it, with the score of
+// the most similar name of each value. Only the values that filter accepts are returned if it is not nil. The matches are not sorted
+func (f *fuzzyIndex) search(query string, filter func(value string) bool) []fuzzyMatch {
+	normalized := normalizeName(query)
+	if normalized == "" {
+		return nil
+	}
+	candidates := f.withinDistance(normalized, getMaxDistance(normalized))
+	if utf8.RuneCountInString(normalized) >= minPrefixLength {
+		candidates = append(candidates, f.withPrefix(normalized)...)
+	}
+	return f.getBestMatches(normalized, candidates, filter, getSimilarity)
+}
+
+// complete : get the values with a name that starts with query, as well as the ones within the maximum edit distance of it if it is at
+// least minPrefixLength long. Names that start with the query always score higher than the ones that only match with typos
+func (f *fuzzyIndex) complete(query string, filter func(value string) bool) []fuzzyMatch {
+	normalized := normalizeName(query)
+	if normalized == "" {
+		return nil
+	}
+	candidates := f.withPrefix(normalized)
+	if utf8.RuneCountInString(normalized) >= minPrefixLength {
+		candidates = append(candidates, f.withinDistance(normalized, getMaxDistance(normalized))...)
+	}
+	return f.getBestMatches(normalized, candidates, filter, getCompletionScore)
+}
+
+// getCompletionScore : score from 0 to 1 of how well term completes query, which is above 0.5 if term starts with query and below it otherwise
+func getCompletionScore(query string, term string) float64 {
+	if strings.HasPrefix(term, query) {
+		return 0.5 + 0.5*float64(utf8.RuneCountInString(query))/float64(utf8.RuneCountInString(term))
+	}
+	return 0.5 * getSimilarity(query, term)
+}
+
+// getBestMatches : score the candidate names against query, keeping the best score of each value that filter accepts
+func (f *fuzzyIndex) getBestMatches(query string, candidates []string, filter func(value string) bool, scoreFn func(query string, term string) float64) []fuzzyMatch {
+	bestMatches := make(map[string]fuzzyMatch)
+	for _, term := range candidates {
+		score := scoreFn(query, term)
+		for _, value := range f.values[term] {
+			if filter != nil && !filter(value) {
+				continue
+			}
+			if match, ok := bestMatches[value]; !ok || score > match.score {
+				bestMatches[value] = fuzzyMatch{value, term, score}
+			}
+		}
+	}
+	matches := make([]fuzzyMatch, 0, len(bestMatches))
+	for _, match := range bestMatches {
+		matches = append(matches, match)
+	}
+	return matches
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var benchmarkSyllables = strings.Fields("al an ar ba be bo ca ce ch co da de di do el en er es fa fe fo ga ge go ha he ho in is ja jo ka ke ki " +
+	"la le li lo ma me mi mo na ne ni no or pa pe pi po ra re ri ro sa se si so ta te ti to va ve vi wa we wi ya yo za ton ville burg ford field wood land")
+
+// getBenchmarkNames : about as many distinct names as there are counties, states and countries in the UID lookup table
+func getBenchmarkNames() []string {
+	random := rand.New(rand.NewSource(1))
+	seen := make(map[string]bool)
+	var names []string
+	for len(names) < 4000 {
+		var builder strings.Builder
+		for i := 2 + random.Intn(3); i > 0; i-- {
+			builder.WriteString(benchmarkSyllables[random.Intn(len(benchmarkSyllables))])
+		}
+		if name := builder.String(); !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func getBenchmarkIndex(names []string) *fuzzyIndex {
+	entries := make([]fuzzyEntry, len(names))
+	for i, name := range names {
+		entries[i] = fuzzyEntry{name, strconv.Itoa(i)}
+	}
+	return newFuzzyIndex(entries)
+}
+
+// linearSearch : compare query against every name, which is what the fuzzy index avoids
+func linearSearch(names []string, query string) []string {
+	normalized := normalizeName(query)
+	maxDistance := getMaxDistance(normalized)
+	var terms []string
+	for _, name := range names {
+		if editDistance([]rune(normalized), []rune(name)) <= maxDistance || (len(normalized) >= minPrefixLength && strings.HasPrefix(name, normalized)) {
+			terms = append(terms, name)
+		}
+	}
+	return terms
+}
+
+func getMatchedTerms(matches []fuzzyMatch) []string {
+	terms := make([]string, len(matches))
+	for i, match := range matches {
+		terms[i] = match.term
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+func TestFuzzyIndex_Get(t *testing.T) {
+	index := newFuzzyIndex([]fuzzyEntry{{"Côte d'Ivoire", "CI"}, {"Ivory Coast", "CI"}, {"cote divoire", "CI"}, {"Georgia", "GE"}, {"Georgia", "US-GA"}, {"", "XX"}, {"Empty", ""}})
+	tables := []struct {
+		name     string
+		expected []string
+	}{
+		{"cote d'ivoire", []string{"CI"}},
+		{"IVORY  COAST", []string{"CI"}},
+		{"Georgia", []string{"GE", "US-GA"}},
+		{"Empty", nil},
+		{"", nil},
+	}
+	for _, table := range tables {
+		if result := index.get(table.name); strings.Join(result, ",") != strings.Join(table.expected, ",") {
+			t.Errorf("Values are incorrect for %s, got: %v, want: %v.", table.name, result, table.expected)
+		}
+	}
+}
+
+func TestFuzzyIndex_WithPrefix(t *testing.T) {
+	index := newFuzzyIndex([]fuzzyEntry{{"United Kingdom", "GB"}, {"United States", "US"}, {"Uganda", "UG"}, {"Unity", "XX"}})
+	if result := index.withPrefix("united"); strings.Join(result, ",") != "united kingdom,united states" {
+		t.Errorf("Names with prefix are incorrect, got: %v, want: %v.", result, []string{"united kingdom", "united states"})
+	}
+	if result := index.withPrefix("z"); len(result) != 0 {
+		t.Errorf("Names with prefix should be empty, got: %v.", result)
+	}
+}
+
+func TestFuzzyIndex_SearchMatchesLinearSearch(t *testing.T) {
+	names := getBenchmarkNames()
+	index := getBenchmarkIndex(names)
+	for _, query := range []string{"santari", "montaville", "kenburston", "co", "zzzzzz", "lamaford", "verdo", "wood", "malofield", "sango"} {
+		result := getMatchedTerms(index.search(query, nil))
+		expected := linearSearch(names, query)
+		sort.Strings(expected)
+		if strings.Join(result, ",") != strings.Join(expected, ",") {
+			t.Errorf("Matches are incorrect for %s, got: %v, want: %v.", query, result, expected)
+		}
+	}
+}
+
+func TestFuzzyIndex_SearchBestScorePerValue(t *testing.T) {
+	index := newFuzzyIndex([]fuzzyEntry{{"Burma", "MM"}, {"Myanmar", "MM"}, {"Bermuda", "BM"}})
+	matches := index.search("burma", func(value string) bool { return value != "BM" })
+	if len(matches) != 1 || matches[0].value != "MM" || matches[0].term != "burma" || matches[0].score != 1 {
+		t.Errorf("Matches are incorrect, got: %+v, want: %+v.", matches, fuzzyMatch{"MM", "burma", 1})
+	}
+	if matches := index.search("", nil); len(matches) != 0 {
+		t.Errorf("Matches should be empty for an empty query, got: %+v.", matches)
+	}
+}
+
+func BenchmarkGetAbbreviationFromCountry_ExactHit(b *testing.B) {
+	setTestCountryLookup()
+	for i := 0; i < b.N; i++ {
+		GetAbbreviationFromCountry("Singapore")
+	}
+}
+
+func BenchmarkGetAbbreviationFromCountry_NormalizedHit(b *testing.B) {
+	setTestCountryLookup()
+	for i := 0; i < b.N; i++ {
+		GetAbbreviationFromCountry("Côte d'Ivoire")
+	}
+}
+
+func BenchmarkGetAbbreviationFromCountry_Miss(b *testing.B) {
+	setTestCountryLookup()
+	for i := 0; i < b.N; i++ {
+		GetAbbreviationFromCountry("Sngapore")
+	}
+}
+
+func BenchmarkFuzzyIndex_Get(b *testing.B) {
+	index := getBenchmarkIndex(getBenchmarkNames())
+	for i := 0; i < b.N; i++ {
+		index.get("Santaville")
+	}
+}
+
+func BenchmarkFuzzyIndex_SearchMiss(b *testing.B) {
+	index := getBenchmarkIndex(getBenchmarkNames())
+	for i := 0; i < b.N; i++ {
+		index.search("kenburstn", nil)
+	}
+}
+
+func BenchmarkLinearSearch_Miss(b *testing.B) {
+	names := getBenchmarkNames()
+	for i := 0; i < b.N; i++ {
+		linearSearch(names, "kenburstn")
+	}
+}
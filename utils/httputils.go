@@ -2,6 +2,8 @@ package utils
 
 import (
 	"encoding/csv"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 )
@@ -11,21 +13,46 @@ type HTTPClient interface {
 	Get(url string) (*http.Response, error)
 }
 
-func ReadCSVFromURL(client HTTPClient, url string) ([][]string, bool) {
+// CSVRowFn : called for every row of a CSV file in order, the header row has index 0. Returning an error stops reading the file.
+type CSVRowFn func(rowIndex int, row []string) error
+
+// StreamCSVFromURL : read the CSV file at url one row at a time, calling rowFn for each row without holding the whole file in memory
+func StreamCSVFromURL(client HTTPClient, url string, rowFn CSVRowFn) error {
 	resp, err := client.Get(url)
 	if err != nil {
-		log.Printf("Error was encountered getting the data from %s: %s\n", url, err.Error())
-		return nil, false
+		return fmt.Errorf("Error was encountered getting the data from %s: %s", url, err.Error())
 	}
 
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Error was encountered getting the data from %s: status %d", url, resp.StatusCode)
+	}
 	reader := csv.NewReader(resp.Body)
 	reader.Comma = ','
-	data, err := reader.ReadAll()
+	for rowIndex := 0; ; rowIndex++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Error was encountered reading the data from %s: %s", url, err.Error())
+		}
+		if err := rowFn(rowIndex, row); err != nil {
+			return fmt.Errorf("Error was encountered in row %d of the data from %s: %s", rowIndex, url, err.Error())
+		}
+	}
+}
+
+// ReadCSVFromURL : read the entire CSV file at url into memory
+func ReadCSVFromURL(client HTTPClient, url string) ([][]string, bool) {
+	var data [][]string
+	err := StreamCSVFromURL(client, url, func(_ int, row []string) error {
+		data = append(data, row)
+		return nil
+	})
 	if err != nil {
-		log.Printf("Error was encountered reading the data from %s: %s\n", url, err.Error())
+		log.Println(err.Error())
 		return nil, false
 	}
-
 	return data, true
 }
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type mockCSVClient struct {
+	csvStr     string
+	statusCode int
+}
+
+func (m *mockCSVClient) Get(url string) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: m.statusCode,
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(m.csvStr))),
+	}, nil
+}
+
+func TestStreamCSVFromURL(t *testing.T) {
+	var rows [][]string
+	var indices []int
+	err := StreamCSVFromURL(&mockCSVClient{"a,b\n1,2\n3,4", 200}, "url", func(rowIndex int, row []string) error {
+		indices = append(indices, rowIndex)
+		rows = append(rows, row)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Error should be nil, got: %s.", err.Error())
+	}
+	if len(rows) != 3 || rows[0][0] != "a" || rows[2][1] != "4" {
+		t.Errorf("Rows are incorrect, got: %v.", rows)
+	}
+	for i, rowIndex := range indices {
+		if rowIndex != i {
+			t.Errorf("Row index is incorrect, got: %d, want: %d.", rowIndex, i)
+		}
+	}
+}
+
+func TestStreamCSVFromURL_StopsWhenRowFnFails(t *testing.T) {
+	numCalls := 0
+	err := StreamCSVFromURL(&mockCSVClient{"a,b\n1,2\n3,4", 200}, "url", func(rowIndex int, row []string) error {
+		numCalls++
+		if rowIndex == 1 {
+			return errors.New("faulty row")
+		}
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "faulty row") || !strings.Contains(err.Error(), "row 1") {
+		t.Errorf("Error is incorrect, got: %v, want error containing: faulty row.", err)
+	}
+	if numCalls != 2 {
+		t.Errorf("Reading should have stopped at the faulty row, got: %d calls, want: %d.", numCalls, 2)
+	}
+}
+
+func TestStreamCSVFromURL_MalformedCSV(t *testing.T) {
+	err := StreamCSVFromURL(&mockCSVClient{"a,b\n1,2,3", 200}, "url", func(int, []string) error { return nil })
+	if err == nil {
+		t.Error("Error should have been returned for a row with the wrong number of fields.")
+	}
+}
+
+func TestStreamCSVFromURL_BadStatus(t *testing.T) {
+	err := StreamCSVFromURL(&mockCSVClient{"a,b", 404}, "url", func(int, []string) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Error is incorrect, got: %v, want error containing: 404.", err)
+	}
+}
+
+func TestReadCSVFromURL(t *testing.T) {
+	data, ok := ReadCSVFromURL(&mockCSVClient{"a,b\n1,2", 200}, "url")
+	if !ok || len(data) != 2 || data[1][1] != "2" {
+		t.Errorf("Data is incorrect, got: %v, %t.", data, ok)
+	}
+	if _, ok := ReadCSVFromURL(&mockCSVClient{"a,b\n1", 200}, "url"); ok {
+		t.Error("ok should be false for a malformed file.")
+	}
+}
@@ -0,0 +1,271 @@
+package utils
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// indices of the columns in the UID lookup table
+const (
+	uidColumn = iota
+	iso2Column
+	iso3Column
+	code3Column
+	fipsColumn
+	countyColumn
+	stateColumn
+	countryColumn
+	latColumn
+	longColumn
+	combinedKeyColumn
+	populationColumn
+)
+
+// Location : a row of the UID lookup table, which is a country, a state or province of a country, or a county of a US state
+type Location struct {
+	UID        string `json:"uid"`
+	ISO2       string `json:"iso2"`
+	ISO3       string `json:"iso3"`
+	Code3      string `json:"code3"`
+	FIPS       string `json:"fips,omitempty"`
+	County     string `json:"county,omitempty"`
+	State      string `json:"state,omitempty"`
+	Country    string `json:"country"`
+	Population int    `json:"population"`
+}
+
+// LocationLookup : lookup of countries, states and counties by any of the identifiers in the UID lookup table, and of the identifiers of a location
+type LocationLookup struct {
+	byUID  map[string]*Location
+	byFIPS map[string]*Location
+	// countries : iso2 of each country to its own row
+	countries map[string]*Location
+	// countryCodes : upper case iso2, iso3, numeric code3 and UID of each country to its iso2
+	countryCodes map[string]string
+	// countryNames : name of each country to its iso2
+	countryNames map[string]string
+	// states : iso2 of each country to the normalised names of its states to their rows
+	states map[string]map[string]*Location
+	// countryIndex : names, iso2 and iso3 codes and common aliases of every country to their UIDs
+	countryIndex *fuzzyIndex
+	// stateIndex : names of the states of every country to their UIDs
+	stateIndex *fuzzyIndex
+	// countyIndex : names of the counties of every US state to their UIDs
+	countyIndex *fuzzyIndex
+}
+
+// Locations : lookup built from the UID lookup table when the package is initialised
+var Locations = NewLocationLookup(nil)
+
+// NewLocationLookup : build the lookup from the rows of the UID lookup table, without the header row
+func NewLocationLookup(rows [][]string) *LocationLookup {
+	lookup := &LocationLookup{
+		make(map[string]*Location),
+		make(map[string]*Location),
+		make(map[string]*Location),
+		make(map[string]string),
+		make(map[string]string),
+		make(map[string]map[string]*Location),
+		nil,
+		nil,
+		nil,
+	}
+	locations := make([]*Location, 0, len(rows))
+	for _, row := range rows {
+		if len(row) <= populationColumn || row[countryColumn] == "" {
+			continue
+		}
+		population, _ := strconv.Atoi(row[populationColumn])
+		location := &Location{row[uidColumn], row[iso2Column], row[iso3Column], row[code3Column], normalizeFIPS(row[fipsColumn]),
+			row[countyColumn], row[stateColumn], row[countryColumn], population}
+		locations = append(locations, location)
+		if location.UID != "" {
+			lookup.byUID[location.UID] = location
+		}
+		if location.FIPS != "" {
+			lookup.byFIPS[location.FIPS] = location
+		}
+		if location.State == "" && location.County == "" && location.ISO2 != "" {
+			if _, ok := lookup.countries[location.ISO2]; !ok {
+				lookup.countries[location.ISO2] = location
+				for _, code := range []string{location.ISO2, location.ISO3, location.Code3, location.UID} {
+					if code != "" {
+						lookup.countryCodes[strings.ToUpper(code)] = location.ISO2
+					}
+				}
+			}
+		}
+	}
+	// states are indexed by the iso2 of the country they belong to, which can be different from their own iso2, such as Puerto Rico in the US
+	var countryEntries []fuzzyEntry
+	for iso, country := range lookup.countries {
+		lookup.countryNames[country.Country] = iso
+		countryEntries = append(countryEntries, fuzzyEntry{country.Country, country.UID}, fuzzyEntry{iso, country.UID}, fuzzyEntry{country.ISO3, country.UID})
+		for _, alias := range countryAliases[iso] {
+			countryEntries = append(countryEntries, fuzzyEntry{alias, country.UID})
+		}
+	}
+	lookup.countryIndex = newFuzzyIndex(countryEntries)
+	var stateEntries, countyEntries []fuzzyEntry
+	for _, location := range locations {
+		iso, ok := lookup.countryNames[location.Country]
+		if location.State == "" || !ok {
+			continue
+		}
+		if location.County != "" {
+			countyEntries = append(countyEntries, fuzzyEntry{location.County, location.UID})
+			continue
+		}
+		if _, ok := lookup.states[iso]; !ok {
+			lookup.states[iso] = make(map[string]*Location)
+		}
+		lookup.states[iso][normalizeName(location.State)] = location
+		stateEntries = append(stateEntries, fuzzyEntry{location.State, location.UID})
+	}
+	lookup.stateIndex = newFuzzyIndex(stateEntries)
+	lookup.countyIndex = newFuzzyIndex(countyEntries)
+	return lookup
+}
+
+// normalizeFIPS : remove the decimal places and leading zeros that some FIPS codes are written with, so 01001 and 1001.0 are the same code
+func normalizeFIPS(fips string) string {
+	fips = strings.TrimSpace(fips)
+	if index := strings.Index(fips, "."); index >= 0 {
+		fips = fips[:index]
+	}
+	return strings.TrimLeft(fips, "0")
+}
+
+// GetCountryCode : get the iso2 of the country identified by its iso2, iso3, numeric code3 or UID
+func (l *LocationLookup) GetCountryCode(code string) (string, bool) {
+	iso, ok := l.countryCodes[strings.ToUpper(strings.TrimSpace(code))]
+	return iso, ok
+}
+
+// FindCountry : get the iso2 of the country identified by its iso2, iso3, numeric code3, UID, name or a common alias such as USA or Burma.
+// Names and aliases are compared without case, diacritics and punctuation
+func (l *LocationLookup) FindCountry(country string) (string, bool) {
+	if iso, ok := l.GetCountryCode(country); ok {
+		return iso, true
+	}
+	if iso, ok := l.countryNames[country]; ok {
+		return iso, true
+	}
+	if countries := l.getByUIDs(l.countryIndex.get(country)); len(countries) > 0 {
+		return countries[0].ISO2, true
+	}
+	return "", false
+}
+
+// GetCountry : get all the identifiers of the country with iso2
+func (l *LocationLookup) GetCountry(iso string) (Location, bool) {
+	if location, ok := l.countries[strings.ToUpper(iso)]; ok {
+		return *location, true
+	}
+	return Location{}, false
+}
+
+// GetByUID : get the country, state or county with the UID
+func (l *LocationLookup) GetByUID(uid string) (Location, bool) {
+	if location, ok := l.byUID[strings.TrimSpace(uid)]; ok {
+		return *location, true
+	}
+	return Location{}, false
+}
+
+// GetByFIPS : get the US state or county with the FIPS code
+func (l *LocationLookup) GetByFIPS(fips string) (Location, bool) {
+	if location, ok := l.byFIPS[normalizeFIPS(fips)]; ok {
+		return *location, true
+	}
+	return Location{}, false
+}
+
+// GetState : get the state of the country with iso2 identified by its name, FIPS code or UID. Names are compared without case, diacritics and punctuation
+func (l *LocationLookup) GetState(iso string, state string) (Location, bool) {
+	if location, ok := l.states[strings.ToUpper(iso)][normalizeName(state)]; ok {
+		return *location, true
+	}
+	for _, getFn := range []func(string) (Location, bool){l.GetByFIPS, l.GetByUID} {
+		if location, ok := getFn(state); ok && location.State != "" && location.County == "" && l.countryNames[location.Country] == strings.ToUpper(iso) {
+			return location, true
+		}
+	}
+	return Location{}, false
+}
+
+// GetCountryISO : get the iso2 of the country that a state or county is in, which can be different from the iso2 of the state itself
+func (l *LocationLookup) GetCountryISO(location Location) (string, bool) {
+	iso, ok := l.countryNames[location.Country]
+	return iso, ok
+}
+
+// FindStates : get the states of every country identified by the name, FIPS code or UID, sorted by country. There is more than one if
+// countries have states with the same name
+func (l *LocationLookup) FindStates(state string) []Location {
+	states := l.getByUIDs(l.stateIndex.get(state))
+	if len(states) == 0 {
+		for _, getFn := range []func(string) (Location, bool){l.GetByFIPS, l.GetByUID} {
+			if location, ok := getFn(state); ok && location.State != "" && location.County == "" {
+				states = append(states, location)
+				break
+			}
+		}
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Country < states[j].Country
+	})
+	return states
+}
+
+// StateSuggestion : a known state that is similar to a state that was not found, with a score from 0 to 1 where 1 is an exact match
+type StateSuggestion struct {
+	ISO     string  `json:"iso"`
+	State   string  `json:"state"`
+	Country string  `json:"country"`
+	Score   float64 `json:"score"`
+}
+
+// SuggestStates : get up to k states of the country with iso2 that are the most similar to state, or of every country if iso2 is empty.
+// Only states within a few edits of state or starting with it are suggested
+func (l *LocationLookup) SuggestStates(iso string, state string, k int) []StateSuggestion {
+	if k <= 0 {
+		return nil
+	}
+	matches := l.stateIndex.search(state, func(uid string) bool {
+		countryISO, _ := l.GetCountryISO(*l.byUID[uid])
+		return iso == "" || countryISO == strings.ToUpper(iso)
+	})
+	suggestions := make([]StateSuggestion, 0, len(matches))
+	for _, match := range matches {
+		location := l.byUID[match.value]
+		countryISO, _ := l.GetCountryISO(*location)
+		suggestions = append(suggestions, StateSuggestion{countryISO, location.State, location.Country, math.Round(match.score*1000) / 1000})
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		if suggestions[i].State != suggestions[j].State {
+			return suggestions[i].State < suggestions[j].State
+		}
+		return suggestions[i].Country < suggestions[j].Country
+	})
+	if len(suggestions) > k {
+		suggestions = suggestions[:k]
+	}
+	return suggestions
+}
+
+// getByUIDs : get the locations with the UIDs
+func (l *LocationLookup) getByUIDs(uids []string) []Location {
+	locations := make([]Location, 0, len(uids))
+	for _, uid := range uids {
+		if location, ok := l.byUID[uid]; ok {
+			locations = append(locations, *location)
+		}
+	}
+	return locations
+}
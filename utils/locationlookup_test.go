@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"testing"
+)
+
+func getTestLocationLookup() *LocationLookup {
+	return NewLocationLookup([][]string{
+		[]string{"840", "US", "USA", "840", "", "", "", "US", "40", "-100", "US", "329466283"},
+		[]string{"84000036", "US", "USA", "840", "36", "", "New York", "US", "42.1657", "-74.9481", "New York, US", "19453561"},
+		[]string{"84036061", "US", "USA", "840", "36061.0", "New York", "New York", "US", "40.7672", "-73.9715", "New York City, New York, US", "1628706"},
+		[]string{"630", "PR", "PRI", "630", "72", "", "Puerto Rico", "US", "18.2208", "-66.5901", "Puerto Rico, US", "3193694"},
+		[]string{"156", "CN", "CHN", "156", "", "", "", "China", "35.8617", "104.1954", "China", "1404676330"},
+		[]string{"15617", "CN", "CHN", "156", "", "", "Hubei", "China", "30.9756", "112.2707", "Hubei, China", "57237740"},
+		[]string{"702", "SG", "SGP", "702", "", "", "", "Singapore", "1.2833", "103.8333", "Singapore", "5850343"},
+		[]string{"124", "CA", "CAN", "124", "", "", "", "Canada", "60", "-95", "Canada", "37855702"},
+		[]string{"12490", "CA", "CAN", "124", "", "", "Diamond Princess", "Canada", "", "", "Diamond Princess, Canada", ""},
+		[]string{"84088888", "US", "USA", "840", "88888", "", "Diamond Princess", "US", "", "", "Diamond Princess, US", ""},
+		[]string{"12411", "CA", "CAN", "124", "", "", "Québec", "Canada", "52.9399", "-73.5491", "Quebec, Canada", "8484965"},
+		[]string{"1", "", "", "", "", "", "", "", "", "", "", ""},
+	})
+}
+
+func TestLocationLookup_GetCountryCode(t *testing.T) {
+	lookup := getTestLocationLookup()
+	tables := []struct {
+		code string
+		iso  string
+		ok   bool
+	}{
+		{"US", "US", true},
+		{"usa", "US", true},
+		{"840", "US", true},
+		{"CHN", "CN", true},
+		{"156", "CN", true},
+		{"702", "SG", true},
+		{"PRI", "", false},
+		{"84000036", "", false},
+		{"XYZ", "", false},
+	}
+	for _, table := range tables {
+		iso, ok := lookup.GetCountryCode(table.code)
+		if iso != table.iso || ok != table.ok {
+			t.Errorf("Country code lookup is incorrect for %s, got: %s %t, want: %s %t.", table.code, iso, ok, table.iso, table.ok)
+		}
+	}
+}
+
+func TestLocationLookup_GetCountry(t *testing.T) {
+	lookup := getTestLocationLookup()
+	location, ok := lookup.GetCountry("sg")
+	expected := Location{"702", "SG", "SGP", "702", "", "", "", "Singapore", 5850343}
+	if !ok || location != expected {
+		t.Errorf("Country is incorrect, got: %+v, want: %+v.", location, expected)
+	}
+}
+
+func TestLocationLookup_GetByFIPS(t *testing.T) {
+	lookup := getTestLocationLookup()
+	tables := []struct {
+		fips   string
+		county string
+		state  string
+		ok     bool
+	}{
+		{"36", "", "New York", true},
+		{"036", "", "New York", true},
+		{"36.0", "", "New York", true},
+		{"36061", "New York", "New York", true},
+		{"72", "", "Puerto Rico", true},
+		{"99", "", "", false},
+		{"", "", "", false},
+	}
+	for _, table := range tables {
+		location, ok := lookup.GetByFIPS(table.fips)
+		if ok != table.ok || location.County != table.county || location.State != table.state {
+			t.Errorf("FIPS lookup is incorrect for %s, got: %+v %t, want: %s %s %t.", table.fips, location, ok, table.county, table.state, table.ok)
+		}
+	}
+}
+
+func TestLocationLookup_GetState(t *testing.T) {
+	lookup := getTestLocationLookup()
+	tables := []struct {
+		iso   string
+		state string
+		name  string
+		ok    bool
+	}{
+		{"US", "New York", "New York", true},
+		{"us", "new york", "New York", true},
+		{"US", "36", "New York", true},
+		{"US", "84000036", "New York", true},
+		{"US", "Puerto Rico", "Puerto Rico", true},
+		{"CN", "Hubei", "Hubei", true},
+		{"CN", "15617", "Hubei", true},
+		{"US", "Hubei", "", false},
+		{"US", "36061", "", false},
+		{"CN", "36", "", false},
+	}
+	for _, table := range tables {
+		location, ok := lookup.GetState(table.iso, table.state)
+		if ok != table.ok || location.State != table.name {
+			t.Errorf("State lookup is incorrect for %s in %s, got: %+v %t, want: %s %t.", table.state, table.iso, location, ok, table.name, table.ok)
+		}
+	}
+}
+
+func TestLocationLookup_GetCountryISO(t *testing.T) {
+	lookup := getTestLocationLookup()
+	location, _ := lookup.GetByFIPS("72")
+	if iso, ok := lookup.GetCountryISO(location); !ok || iso != "US" {
+		t.Errorf("Country of Puerto Rico is incorrect, got: %s, want: %s.", iso, "US")
+	}
+}
+
+func TestGetAbbreviationFromCountry_NumericCode(t *testing.T) {
+	setTestCountryLookup()
+	defer func() { Locations = NewLocationLookup(nil) }()
+	Locations = getTestLocationLookup()
+	if iso, ok := GetAbbreviationFromCountry("702"); !ok || iso != "SG" {
+		t.Errorf("iso is incorrect for %s, got: %s, want: %s.", "702", iso, "SG")
+	}
+}
+
+func TestLocationLookup_GetState_IgnoresDiacritics(t *testing.T) {
+	lookup := getTestLocationLookup()
+	if location, ok := lookup.GetState("CA", "quebec"); !ok || location.State != "Québec" {
+		t.Errorf("State lookup is incorrect for quebec, got: %+v %t, want: %s.", location, ok, "Québec")
+	}
+}
+
+func TestLocationLookup_FindStates(t *testing.T) {
+	lookup := getTestLocationLookup()
+	tables := []struct {
+		state     string
+		countries []string
+	}{
+		{"hubei", []string{"China"}},
+		{"Diamond Princess", []string{"Canada", "US"}},
+		{"36", []string{"US"}},
+		{"15617", []string{"China"}},
+		{"36061", []string{}},
+		{"Hubie", []string{}},
+	}
+	for _, table := range tables {
+		locations := lookup.FindStates(table.state)
+		if len(locations) != len(table.countries) {
+			t.Errorf("Number of states found is incorrect for %s, got: %+v, want: %v.", table.state, locations, table.countries)
+			continue
+		}
+		for i, location := range locations {
+			if location.Country != table.countries[i] {
+				t.Errorf("Country of state is incorrect for %s, got: %s, want: %s.", table.state, location.Country, table.countries[i])
+			}
+		}
+	}
+}
+
+func TestLocationLookup_SuggestStates(t *testing.T) {
+	lookup := getTestLocationLookup()
+	suggestions := lookup.SuggestStates("", "Nw York", 2)
+	if len(suggestions) != 1 || suggestions[0].State != "New York" || suggestions[0].ISO != "US" {
+		t.Errorf("Suggestions are incorrect, got: %+v, want only New York.", suggestions)
+	}
+	if suggestions := lookup.SuggestStates("", "Diamond", 5); len(suggestions) != 2 || suggestions[0].Country != "Canada" || suggestions[1].Country != "US" {
+		t.Errorf("Suggestions should contain the states starting with the query in every country, got: %+v.", suggestions)
+	}
+	if suggestions := lookup.SuggestStates("CN", "Nw York", 5); len(suggestions) != 0 {
+		t.Errorf("Suggestions should only contain states in China, got: %+v.", suggestions)
+	}
+	if suggestions := lookup.SuggestStates("CN", "Hubie", 5); len(suggestions) != 1 || suggestions[0].State != "Hubei" {
+		t.Errorf("Suggestions are incorrect, got: %+v, want only Hubei.", suggestions)
+	}
+	if suggestions := lookup.SuggestStates("US", "", 5); len(suggestions) != 0 {
+		t.Errorf("Suggestions should be empty for an empty state, got: %+v.", suggestions)
+	}
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+const workerPoolSizeEnvironmentVar = "WORKER_POOL_SIZE"
+
+// WorkerPool : fixed number of long lived workers that run tasks, so that fanning out work does not start a goroutine per item
+type WorkerPool struct {
+	tasks chan func()
+}
+
+// DefaultWorkerPool : worker pool shared by all packages, sized by the WORKER_POOL_SIZE environment variable or the number of CPUs
+var DefaultWorkerPool *WorkerPool
+
+func init() {
+	DefaultWorkerPool = NewWorkerPool(getWorkerPoolSize())
+}
+
+func getWorkerPoolSize() int {
+	if size, err := strconv.Atoi(os.Getenv(workerPoolSizeEnvironmentVar)); err == nil && size > 0 {
+		return size
+	}
+	if os.Getenv(workerPoolSizeEnvironmentVar) != "" {
+		log.Printf("Invalid value for %s, using the number of CPUs instead.\n", workerPoolSizeEnvironmentVar)
+	}
+	return runtime.NumCPU()
+}
+
+// NewWorkerPool : start a worker pool with numWorkers workers
+func NewWorkerPool(numWorkers int) *WorkerPool {
+	pool := &WorkerPool{make(chan func())}
+	for i := 0; i < numWorkers; i++ {
+		go pool.work()
+	}
+	return pool
+}
+
+func (p *WorkerPool) work() {
+	for task := range p.tasks {
+		task()
+	}
+}
+
+// Run : call taskFn for every index from 0 to numTasks-1 and wait for all of them to finish.
+// A task is handed to an idle worker if there is one, otherwise the caller runs it itself, so Run never blocks waiting for a worker
+// and can safely be called from inside another task.
+func (p *WorkerPool) Run(numTasks int, taskFn func(index int)) {
+	wg := sync.WaitGroup{}
+	wg.Add(numTasks)
+	for i := 0; i < numTasks; i++ {
+		index := i
+		task := func() {
+			defer wg.Done()
+			taskFn(index)
+		}
+		select {
+		case p.tasks <- task:
+		default:
+			task()
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWorkerPoolRun_RunsEveryTask(t *testing.T) {
+	pool := NewWorkerPool(4)
+	results := make([]int, 100)
+	pool.Run(len(results), func(index int) {
+		results[index] = index * 2
+	})
+	for i, result := range results {
+		if result != i*2 {
+			t.Errorf("Result of task %d is incorrect, got: %d, want: %d.", i, result, i*2)
+		}
+	}
+}
+
+func TestWorkerPoolRun_BoundsConcurrency(t *testing.T) {
+	numWorkers := 3
+	pool := NewWorkerPool(numWorkers)
+	var running, maxRunning int32
+	pool.Run(50, func(index int) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		runtime.Gosched()
+		atomic.AddInt32(&running, -1)
+	})
+	// the workers and the calling goroutine can run tasks at the same time
+	if maxRunning > int32(numWorkers+1) {
+		t.Errorf("Too many tasks ran at the same time, got: %d, want at most: %d.", maxRunning, numWorkers+1)
+	}
+}
+
+func TestWorkerPoolRun_NestedRunDoesNotDeadlock(t *testing.T) {
+	pool := NewWorkerPool(1)
+	var count int32
+	pool.Run(4, func(int) {
+		pool.Run(4, func(int) {
+			atomic.AddInt32(&count, 1)
+		})
+	})
+	if count != 16 {
+		t.Errorf("Number of nested tasks run is incorrect, got: %d, want: %d.", count, 16)
+	}
+}
+
+func TestGetWorkerPoolSize(t *testing.T) {
+	defer os.Unsetenv(workerPoolSizeEnvironmentVar)
+	tables := []struct {
+		value    string
+		expected int
+	}{
+		{"8", 8},
+		{"", runtime.NumCPU()},
+		{"0", runtime.NumCPU()},
+		{"abc", runtime.NumCPU()},
+	}
+	for _, table := range tables {
+		os.Setenv(workerPoolSizeEnvironmentVar, table.value)
+		if size := getWorkerPoolSize(); size != table.expected {
+			t.Errorf("Worker pool size for %q is incorrect, got: %d, want: %d.", table.value, size, table.expected)
+		}
+	}
+}
+
+func benchmarkTask(results []int, index int) {
+	sum := 0
+	for i := 0; i < 1000; i++ {
+		sum += i * index
+	}
+	results[index] = sum
+}
+
+func BenchmarkGoroutinePerTask(b *testing.B) {
+	results := make([]int, 5000)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		wg := sync.WaitGroup{}
+		for index := range results {
+			wg.Add(1)
+			go func(index int) {
+				benchmarkTask(results, index)
+				wg.Done()
+			}(index)
+		}
+		wg.Wait()
+	}
+}
+
+func BenchmarkWorkerPool(b *testing.B) {
+	results := make([]int, 5000)
+	pool := NewWorkerPool(runtime.NumCPU())
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Run(len(results), func(index int) {
+			benchmarkTask(results, index)
+		})
+	}
+}